@@ -0,0 +1,179 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+// Store is a content-addressed artifact store. Keys are derived from the
+// artifact hash (see Key), so an artifact never changes once stored.
+type Store interface {
+	// Get opens the artifact stored under key, or returns ErrNotFound
+	Get(ctx context.Context, key string) (*Object, error)
+
+	// Create returns a writer that stores an artifact under key once committed
+	Create(ctx context.Context, key string) (Writer, error)
+
+	Close() error
+}
+
+// Object is a stored artifact
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// Writer receives the content of an artifact
+type Writer interface {
+	io.Writer
+
+	// Commit verifies the content against the key's hash and stores it.
+	// Content that does not match is discarded and ErrChecksumMismatch returned.
+	Commit() error
+
+	// Abort discards the content written so far
+	Abort()
+}
+
+var (
+	// ErrNotFound is returned when an artifact is not stored
+	ErrNotFound = errors.New("artifact not found")
+
+	// ErrChecksumMismatch is returned when content does not match its key
+	ErrChecksumMismatch = errors.New("artifact checksum mismatch")
+)
+
+// Key returns the store key of a download: its upstream SHA-256, or else its
+// SHA-1. Downloads without a hash reported upstream cannot be stored.
+func Key(d models.Download) (string, bool) {
+	switch {
+	case d.SHA256 != "" && !d.SHA256Computed:
+		return "sha256/" + strings.ToLower(d.SHA256), true
+	case d.SHA1 != "":
+		return "sha1/" + strings.ToLower(d.SHA1), true
+	default:
+		return "", false
+	}
+}
+
+// ContentType returns the content type of a download by its file name
+func ContentType(filename string) string {
+	ext := path.Ext(filename)
+	if ext == ".jar" {
+		return "application/java-archive"
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// parseKey returns a new hash for the key's algorithm and the expected digest
+func parseKey(key string) (hash.Hash, string, error) {
+	algorithm, digest, ok := strings.Cut(key, "/")
+	if !ok || digest == "" || strings.Trim(digest, "0123456789abcdef") != "" {
+		return nil, "", fmt.Errorf("invalid artifact key %q", key)
+	}
+
+	switch algorithm {
+	case "sha256":
+		if len(digest) != sha256.Size*2 {
+			return nil, "", fmt.Errorf("invalid artifact key %q", key)
+		}
+		return sha256.New(), digest, nil
+	case "sha1":
+		if len(digest) != sha1.Size*2 {
+			return nil, "", fmt.Errorf("invalid artifact key %q", key)
+		}
+		return sha1.New(), digest, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported artifact hash %q", algorithm)
+	}
+}
+
+// verify reports whether a hash matches the expected hex digest
+func verify(h hash.Hash, digest string) bool {
+	return hex.EncodeToString(h.Sum(nil)) == digest
+}
+
+// Config holds artifact store configuration
+type Config struct {
+	Dir     string // Local directory (empty = disabled unless S3 is configured)
+	MaxSize int64  // Bytes; least recently used artifacts are evicted above it
+
+	S3 S3Config // Used instead of Dir when S3.Endpoint is set
+}
+
+// DefaultConfig returns default artifact store configuration from environment
+func DefaultConfig() Config {
+	maxSizeMB := int64(10 * 1024) // 10 GB default
+	if sizeStr := os.Getenv("ARTIFACT_CACHE_MAX_SIZE_MB"); sizeStr != "" {
+		if parsed, err := strconv.ParseInt(sizeStr, 10, 64); err == nil && parsed > 0 {
+			maxSizeMB = parsed
+		}
+	}
+
+	var presignTTL time.Duration
+	if os.Getenv("ARTIFACT_S3_REDIRECT") == "true" {
+		presignTTL = 15 * time.Minute
+		if d, err := time.ParseDuration(os.Getenv("ARTIFACT_S3_PRESIGN_TTL")); err == nil && d > 0 {
+			presignTTL = d
+		}
+	}
+
+	return Config{
+		Dir:     os.Getenv("ARTIFACT_CACHE_DIR"),
+		MaxSize: maxSizeMB << 20,
+		S3: S3Config{
+			Endpoint:   os.Getenv("ARTIFACT_S3_ENDPOINT"),
+			Bucket:     os.Getenv("ARTIFACT_S3_BUCKET"),
+			Prefix:     os.Getenv("ARTIFACT_S3_PREFIX"),
+			Region:     os.Getenv("ARTIFACT_S3_REGION"),
+			AccessKey:  os.Getenv("ARTIFACT_S3_ACCESS_KEY"),
+			SecretKey:  os.Getenv("ARTIFACT_S3_SECRET_KEY"),
+			Secure:     os.Getenv("ARTIFACT_S3_SECURE") != "false",
+			PresignTTL: presignTTL,
+		},
+	}
+}
+
+// New creates an artifact store based on configuration
+// Returns an S3 store if configured, a disk store if a directory is
+// configured, and nil otherwise
+func New(cfg Config) (Store, error) {
+	if cfg.S3.Endpoint != "" {
+		store, err := NewS3Store(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Using S3 artifact mirror (%s/%s)\n", cfg.S3.Endpoint, cfg.S3.Bucket)
+		return store, nil
+	}
+
+	if cfg.Dir == "" {
+		fmt.Println("Artifact cache disabled")
+		return nil, nil
+	}
+
+	store, err := NewDiskStore(cfg.Dir, cfg.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Using artifact cache in %s\n", cfg.Dir)
+	return store, nil
+}
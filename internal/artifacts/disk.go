@@ -0,0 +1,276 @@
+package artifacts
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskStore implements Store on the local filesystem with a size limit.
+// The least recently used artifacts are evicted when the limit is exceeded.
+type DiskStore struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List // Most recently used first; values are *diskEntry
+	entries map[string]*list.Element
+}
+
+// diskEntry is an artifact tracked by the LRU index
+type diskEntry struct {
+	key  string
+	size int64
+}
+
+// NewDiskStore creates a disk store in dir, indexing the artifacts already
+// stored there. Access times survive restarts through file modification times.
+func NewDiskStore(dir string, maxSize int64) (*DiskStore, error) {
+	s := &DiskStore{
+		dir:     dir,
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+
+	// Leftovers of interrupted downloads
+	_ = os.RemoveAll(s.tmpDir())
+	if err := os.MkdirAll(s.tmpDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("creating artifact cache directory: %w", err)
+	}
+
+	if err := s.index(); err != nil {
+		return nil, fmt.Errorf("indexing artifact cache: %w", err)
+	}
+
+	s.mu.Lock()
+	s.evict()
+	s.mu.Unlock()
+
+	return s, nil
+}
+
+// index loads the stored artifacts into the LRU index, oldest last
+func (s *DiskStore) index() error {
+	type stored struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var artifacts []stored
+
+	for _, algorithm := range []string{"sha256", "sha1"} {
+		root := filepath.Join(s.dir, algorithm)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			key := algorithm + "/" + d.Name()
+			if _, _, err := parseKey(key); err != nil {
+				return nil
+			}
+			artifacts = append(artifacts, stored{key: key, size: info.Size(), modTime: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].modTime.After(artifacts[j].modTime)
+	})
+
+	for _, a := range artifacts {
+		s.entries[a.key] = s.lru.PushBack(&diskEntry{key: a.key, size: a.size})
+		s.size += a.size
+	}
+
+	return nil
+}
+
+// path returns the file path of an artifact
+func (s *DiskStore) path(key string) string {
+	algorithm, digest, _ := strings.Cut(key, "/")
+	return filepath.Join(s.dir, algorithm, digest[:2], digest)
+}
+
+func (s *DiskStore) tmpDir() string {
+	return filepath.Join(s.dir, "tmp")
+}
+
+func (s *DiskStore) Get(_ context.Context, key string) (*Object, error) {
+	if _, _, err := parseKey(key); err != nil {
+		return nil, err
+	}
+
+	path := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			s.remove(key)
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("opening artifact: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("opening artifact: %w", err)
+	}
+
+	s.touch(key, info.Size())
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return &Object{
+		ReadSeekCloser: f,
+		Size:           info.Size(),
+		ModTime:        info.ModTime(),
+	}, nil
+}
+
+func (s *DiskStore) Create(_ context.Context, key string) (Writer, error) {
+	h, digest, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(s.tmpDir(), "artifact-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating artifact: %w", err)
+	}
+
+	return &diskWriter{
+		store:  s,
+		key:    key,
+		file:   f,
+		hash:   h,
+		digest: digest,
+	}, nil
+}
+
+func (s *DiskStore) Close() error {
+	return nil
+}
+
+// touch marks an artifact as most recently used, adding it to the index if needed
+func (s *DiskStore) touch(key string, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*diskEntry)
+		s.size += size - entry.size
+		entry.size = size
+		s.lru.MoveToFront(elem)
+	} else {
+		s.entries[key] = s.lru.PushFront(&diskEntry{key: key, size: size})
+		s.size += size
+	}
+
+	s.evict()
+}
+
+// remove drops an artifact from the index
+func (s *DiskStore) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.size -= elem.Value.(*diskEntry).size
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+	}
+}
+
+// evict deletes least recently used artifacts until the store fits maxSize.
+// Must be called with mu held.
+func (s *DiskStore) evict() {
+	for s.maxSize > 0 && s.size > s.maxSize {
+		elem := s.lru.Back()
+		if elem == nil {
+			return
+		}
+
+		entry := elem.Value.(*diskEntry)
+		if err := os.Remove(s.path(entry.key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: Failed to evict artifact %s: %v", entry.key, err)
+		}
+
+		s.size -= entry.size
+		s.lru.Remove(elem)
+		delete(s.entries, entry.key)
+	}
+}
+
+// diskWriter writes an artifact to a temporary file, moved into place on commit
+type diskWriter struct {
+	store  *DiskStore
+	key    string
+	file   *os.File
+	hash   hash.Hash
+	digest string
+	size   int64
+}
+
+func (w *diskWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *diskWriter) Commit() error {
+	if err := w.file.Close(); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("writing artifact: %w", err)
+	}
+
+	if !verify(w.hash, w.digest) {
+		_ = os.Remove(w.file.Name())
+		return ErrChecksumMismatch
+	}
+
+	path := w.store.path(w.key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("storing artifact: %w", err)
+	}
+	if err := os.Rename(w.file.Name(), path); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("storing artifact: %w", err)
+	}
+
+	w.store.touch(w.key, w.size)
+	return nil
+}
+
+func (w *diskWriter) Abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
@@ -0,0 +1,141 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// store writes content to s under its SHA-256 key and returns the key
+func store(t *testing.T, s *DiskStore, content string) string {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(content))
+	key := "sha256/" + hex.EncodeToString(sum[:])
+
+	w, err := s.Create(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	return key
+}
+
+func TestDiskStoreRoundTrip(t *testing.T) {
+	s, err := NewDiskStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	key := store(t, s, "server jar")
+
+	obj, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer obj.Close()
+
+	data, _ := io.ReadAll(obj)
+	if string(data) != "server jar" || obj.Size != int64(len(data)) {
+		t.Errorf("Get() = %q (size %d), want %q", data, obj.Size, "server jar")
+	}
+}
+
+func TestDiskStoreRejectsMismatch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("expected"))
+	key := "sha256/" + hex.EncodeToString(sum[:])
+
+	w, err := s.Create(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	_, _ = io.WriteString(w, "tampered")
+	if err := w.Commit(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Commit() error = %v, want ErrChecksumMismatch", err)
+	}
+
+	if _, err := s.Get(context.Background(), key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("%d temporary files left behind", len(tmp))
+	}
+}
+
+func TestDiskStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s, err := NewDiskStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	first := store(t, s, "aaaa")
+	second := store(t, s, "bbbb")
+
+	// Using the first artifact makes the second the least recently used
+	obj, err := s.Get(context.Background(), first)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	obj.Close()
+
+	third := store(t, s, "cccc")
+
+	if _, err := s.Get(context.Background(), second); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(second) error = %v, want ErrNotFound", err)
+	}
+	for _, key := range []string{first, third} {
+		obj, err := s.Get(context.Background(), key)
+		if err != nil {
+			t.Errorf("Get(%s) error = %v", key, err)
+			continue
+		}
+		obj.Close()
+	}
+}
+
+func TestDiskStoreIndexesOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	old := store(t, s, "aaaa")
+	recent := store(t, s, "bbbb")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(s.path(old), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening with a smaller limit evicts the oldest artifact
+	s, err = NewDiskStore(dir, 6)
+	if err != nil {
+		t.Fatalf("NewDiskStore() error = %v", err)
+	}
+
+	if _, err := s.Get(context.Background(), old); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(old) error = %v, want ErrNotFound", err)
+	}
+	obj, err := s.Get(context.Background(), recent)
+	if err != nil {
+		t.Fatalf("Get(recent) error = %v", err)
+	}
+	obj.Close()
+}
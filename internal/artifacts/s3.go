@@ -0,0 +1,187 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config holds S3-compatible bucket configuration
+type S3Config struct {
+	Endpoint  string // host[:port], e.g. "s3.amazonaws.com" or "minio:9000"
+	Bucket    string
+	Prefix    string // Key prefix inside the bucket
+	Region    string
+	AccessKey string
+	SecretKey string
+	Secure    bool // Use HTTPS
+
+	// PresignTTL enables redirecting clients to presigned URLs valid for
+	// this long instead of streaming through the API (0 = stream)
+	PresignTTL time.Duration
+}
+
+// Presigner is implemented by stores that can redirect clients to a
+// temporary direct download URL instead of streaming through the API
+type Presigner interface {
+	// PresignGet returns a temporary URL for the artifact stored under key.
+	// ok is false if presigned redirects are disabled.
+	PresignGet(ctx context.Context, key, filename string) (u string, ok bool, err error)
+}
+
+// S3Store implements Store in an S3-compatible bucket, so multiple API
+// replicas can share one mirror
+type S3Store struct {
+	client *minio.Client
+	config S3Config
+}
+
+// NewS3Store creates an S3 store, checking that the bucket exists
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.Secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("checking S3 bucket: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("S3 bucket %s does not exist", cfg.Bucket)
+	}
+
+	return &S3Store{client: client, config: cfg}, nil
+}
+
+// objectName returns the bucket object name of an artifact
+func (s *S3Store) objectName(key string) string {
+	return path.Join(s.config.Prefix, key)
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (*Object, error) {
+	if _, _, err := parseKey(key); err != nil {
+		return nil, err
+	}
+
+	obj, err := s.client.GetObject(ctx, s.config.Bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("opening artifact: %w", err)
+	}
+
+	info, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("opening artifact: %w", err)
+	}
+
+	return &Object{
+		ReadSeekCloser: obj,
+		Size:           info.Size,
+		ModTime:        info.LastModified,
+	}, nil
+}
+
+func (s *S3Store) Create(ctx context.Context, key string) (Writer, error) {
+	h, digest, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Buffered on disk so the content is verified before it is uploaded
+	f, err := os.CreateTemp("", "artifact-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating artifact: %w", err)
+	}
+
+	return &s3Writer{
+		ctx:    context.WithoutCancel(ctx),
+		store:  s,
+		key:    key,
+		file:   f,
+		hash:   h,
+		digest: digest,
+	}, nil
+}
+
+func (s *S3Store) PresignGet(ctx context.Context, key, filename string) (string, bool, error) {
+	if s.config.PresignTTL <= 0 {
+		return "", false, nil
+	}
+
+	params := url.Values{}
+	params.Set("response-content-type", ContentType(filename))
+	params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	u, err := s.client.PresignedGetObject(ctx, s.config.Bucket, s.objectName(key), s.config.PresignTTL, params)
+	if err != nil {
+		return "", false, fmt.Errorf("presigning artifact: %w", err)
+	}
+	return u.String(), true, nil
+}
+
+func (s *S3Store) Close() error {
+	return nil
+}
+
+// s3Writer writes an artifact to a temporary file, uploaded on commit
+type s3Writer struct {
+	ctx    context.Context
+	store  *S3Store
+	key    string
+	file   *os.File
+	hash   hash.Hash
+	digest string
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	return n, err
+}
+
+func (w *s3Writer) Commit() error {
+	defer func() {
+		_ = os.Remove(w.file.Name())
+	}()
+
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("writing artifact: %w", err)
+	}
+
+	if !verify(w.hash, w.digest) {
+		return ErrChecksumMismatch
+	}
+
+	_, err := w.store.client.FPutObject(w.ctx, w.store.config.Bucket, w.store.objectName(w.key), w.file.Name(), minio.PutObjectOptions{
+		ContentType: "application/java-archive",
+	})
+	if err != nil {
+		return fmt.Errorf("uploading artifact: %w", err)
+	}
+
+	return nil
+}
+
+func (w *s3Writer) Abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
@@ -0,0 +1,119 @@
+package artifacts
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+// MismatchError describes content that does not match its expected hash.
+// It wraps ErrChecksumMismatch.
+type MismatchError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+func (e *MismatchError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// Verifier hashes content as it is written and checks it against the
+// expected hash of a download
+type Verifier struct {
+	hash      hash.Hash
+	algorithm string
+	expected  string
+}
+
+// NewVerifier returns a verifier for the download's SHA-256, or else its
+// SHA-1, or else its MD5. Downloads without a known hash cannot be verified.
+func NewVerifier(d models.Download) (*Verifier, bool) {
+	key, ok := Key(d)
+	if !ok {
+		if d.MD5 == "" {
+			return nil, false
+		}
+		return &Verifier{hash: md5.New(), algorithm: "md5", expected: strings.ToLower(d.MD5)}, true
+	}
+
+	h, expected, err := parseKey(key)
+	if err != nil {
+		return nil, false
+	}
+
+	return &Verifier{
+		hash:      h,
+		algorithm: key[:len(key)-len(expected)-1],
+		expected:  expected,
+	}, true
+}
+
+func (v *Verifier) Write(p []byte) (int, error) {
+	return v.hash.Write(p)
+}
+
+// Verify returns a *MismatchError if the content written so far does not
+// match the expected hash
+func (v *Verifier) Verify() error {
+	if actual := hex.EncodeToString(v.hash.Sum(nil)); actual != v.expected {
+		return &MismatchError{Algorithm: v.algorithm, Expected: v.expected, Actual: actual}
+	}
+	return nil
+}
+
+// Incident records a download whose content did not match its expected hash
+type Incident struct {
+	Time      time.Time `json:"time"`
+	Category  string    `json:"category"`
+	Version   string    `json:"version"`
+	Build     string    `json:"build"`
+	Algorithm string    `json:"algorithm"`
+	Expected  string    `json:"expected"`
+	Actual    string    `json:"actual"`
+}
+
+// IncidentLog keeps the most recent checksum incidents in memory
+type IncidentLog struct {
+	max       int
+	mu        sync.Mutex
+	incidents []Incident
+}
+
+// NewIncidentLog creates a log keeping the last max incidents
+func NewIncidentLog(max int) *IncidentLog {
+	return &IncidentLog{max: max}
+}
+
+// Record adds an incident, dropping the oldest one if the log is full
+func (l *IncidentLog) Record(i Incident) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.incidents = append(l.incidents, i)
+	if len(l.incidents) > l.max {
+		l.incidents = l.incidents[len(l.incidents)-l.max:]
+	}
+}
+
+// Recent returns the recorded incidents, newest first
+func (l *IncidentLog) Recent() []Incident {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	incidents := make([]Incident, len(l.incidents))
+	for i, incident := range l.incidents {
+		incidents[len(l.incidents)-1-i] = incident
+	}
+	return incidents
+}
@@ -0,0 +1,94 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewVerifier(t *testing.T) {
+	const content = "server jar"
+
+	tests := []struct {
+		name          string
+		download      models.Download
+		wantAlgorithm string
+		wantOk        bool
+	}{
+		{
+			name:          "sha256 preferred",
+			download:      models.Download{SHA256: "D9298A10D1B0735837DC4BD85DAC641B0F3CEF27A47E5D53A54F2F3F5B2FCFFA", SHA1: "ab"},
+			wantAlgorithm: "sha256",
+			wantOk:        true,
+		},
+		{
+			name:          "computed sha256 ignored",
+			download:      models.Download{SHA256: "00", SHA256Computed: true, SHA1: "d0941e68da8f38151ff86a61fc59f7c5cf9fcaa2"},
+			wantAlgorithm: "sha1",
+			wantOk:        true,
+		},
+		{
+			name:          "md5 fallback",
+			download:      models.Download{MD5: "795F3202B17CB6BC3D4B771D8C6C9EAF"},
+			wantAlgorithm: "md5",
+			wantOk:        true,
+		},
+		{
+			name:     "no hash",
+			download: models.Download{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := NewVerifier(tt.download)
+			if ok != tt.wantOk {
+				t.Fatalf("NewVerifier() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+
+			_, _ = io.WriteString(v, content)
+			var mismatch *MismatchError
+			if err := v.Verify(); !errors.As(err, &mismatch) || !errors.Is(err, ErrChecksumMismatch) {
+				t.Fatalf("Verify() error = %v, want *MismatchError", err)
+			}
+			if mismatch.Algorithm != tt.wantAlgorithm {
+				t.Errorf("Algorithm = %q, want %q", mismatch.Algorithm, tt.wantAlgorithm)
+			}
+		})
+	}
+}
+
+func TestVerifierMatch(t *testing.T) {
+	v, ok := NewVerifier(models.Download{SHA256: sha256Hex("server jar")})
+	if !ok {
+		t.Fatal("NewVerifier() ok = false")
+	}
+	_, _ = io.WriteString(v, "server jar")
+	if err := v.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestIncidentLogKeepsRecent(t *testing.T) {
+	l := NewIncidentLog(2)
+	for _, build := range []string{"1", "2", "3"} {
+		l.Record(Incident{Build: build})
+	}
+
+	recent := l.Recent()
+	if len(recent) != 2 || recent[0].Build != "3" || recent[1].Build != "2" {
+		t.Errorf("Recent() = %+v, want builds 3 and 2", recent)
+	}
+}
@@ -2,10 +2,13 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,17 +16,94 @@ import (
 )
 
 // Cache interface for caching operations
+//
+// Entries have a soft and a hard expiry. Past the soft expiry an entry is
+// still returned (stale-while-revalidate) but Entry.Fresh reports false so
+// callers can refresh it; past the hard expiry it is a cache miss.
 type Cache interface {
 	Get(ctx context.Context, key string, dest interface{}) error
+	GetEntry(ctx context.Context, key string, dest interface{}) (*Entry, error)
 	Set(ctx context.Context, key string, value interface{}) error
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	SetWithExpiry(ctx context.Context, key string, value interface{}, soft, hard time.Duration) error
 	Delete(ctx context.Context, key string) error
+	// DeletePrefix deletes every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
 	Close() error
 }
 
+// Locker is implemented by caches shared between replicas, so only one
+// replica fetches a given key from upstream at a time
+type Locker interface {
+	// TryLock acquires the lock for key without blocking. The lock expires
+	// after ttl if unlock is never called.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+}
+
+// Entry describes a cached value
+type Entry struct {
+	StoredAt   time.Time
+	SoftExpiry time.Time
+}
+
+// Fresh reports whether the entry is before its soft expiry
+func (e *Entry) Fresh() bool {
+	return time.Now().Before(e.SoftExpiry)
+}
+
+// Age returns how long ago the entry was stored
+func (e *Entry) Age() time.Duration {
+	if e.StoredAt.IsZero() {
+		return 0
+	}
+	return time.Since(e.StoredAt)
+}
+
+// envelope is the serialized form of a cache entry
+type envelope struct {
+	StoredAt   time.Time       `json:"stored_at"`
+	SoftExpiry time.Time       `json:"soft_expiry"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// encode wraps a value in an envelope
+func encode(value interface{}, soft time.Duration) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling data: %w", err)
+	}
+
+	now := time.Now()
+	return json.Marshal(envelope{
+		StoredAt:   now,
+		SoftExpiry: now.Add(soft),
+		Data:       data,
+	})
+}
+
+// decode unwraps an envelope into dest
+func decode(raw []byte, dest interface{}) (*Entry, error) {
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil || env.Data == nil {
+		// Plain values written before entries were wrapped: treat as soft-expired
+		if err := json.Unmarshal(raw, dest); err != nil {
+			return nil, fmt.Errorf("unmarshaling cached data: %w", err)
+		}
+		return &Entry{}, nil
+	}
+
+	if err := json.Unmarshal(env.Data, dest); err != nil {
+		return nil, fmt.Errorf("unmarshaling cached data: %w", err)
+	}
+
+	return &Entry{StoredAt: env.StoredAt, SoftExpiry: env.SoftExpiry}, nil
+}
+
 // Config holds cache configuration
 type Config struct {
 	RedisURL string
-	TTL      time.Duration
+	TTL      time.Duration // Soft expiry: entries older than this are refreshed in the background
+	HardTTL  time.Duration // Hard expiry: entries older than this are evicted
 }
 
 // DefaultConfig returns default cache configuration from environment
@@ -35,16 +115,28 @@ func DefaultConfig() Config {
 		}
 	}
 
+	hardTTL := 3600 // 1 hour default
+	if ttlStr := os.Getenv("CACHE_HARD_TTL"); ttlStr != "" {
+		if parsed, err := strconv.Atoi(ttlStr); err == nil {
+			hardTTL = parsed
+		}
+	}
+	if hardTTL < ttl {
+		hardTTL = ttl
+	}
+
 	return Config{
 		RedisURL: os.Getenv("REDIS_URL"),
 		TTL:      time.Duration(ttl) * time.Second,
+		HardTTL:  time.Duration(hardTTL) * time.Second,
 	}
 }
 
 // RedisCache implements Cache using Redis
 type RedisCache struct {
-	client *redis.Client
-	ttl    time.Duration
+	client  *redis.Client
+	ttl     time.Duration
+	hardTTL time.Duration
 }
 
 // NewRedisCache creates a new Redis cache
@@ -65,40 +157,81 @@ func NewRedisCache(cfg Config) (*RedisCache, error) {
 	}
 
 	return &RedisCache{
-		client: client,
-		ttl:    cfg.TTL,
+		client:  client,
+		ttl:     cfg.TTL,
+		hardTTL: cfg.HardTTL,
 	}, nil
 }
 
 func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) error {
+	_, err := c.GetEntry(ctx, key, dest)
+	return err
+}
+
+func (c *RedisCache) GetEntry(ctx context.Context, key string, dest interface{}) (*Entry, error) {
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
-			return ErrCacheMiss
+			return nil, ErrCacheMiss
 		}
-		return fmt.Errorf("redis get: %w", err)
+		return nil, fmt.Errorf("redis get: %w", err)
 	}
 
-	if err := json.Unmarshal(data, dest); err != nil {
-		return fmt.Errorf("unmarshaling cached data: %w", err)
-	}
-
-	return nil
+	return decode(data, dest)
 }
 
 func (c *RedisCache) Set(ctx context.Context, key string, value interface{}) error {
-	data, err := json.Marshal(value)
+	return c.SetWithExpiry(ctx, key, value, c.ttl, c.hardTTL)
+}
+
+func (c *RedisCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithExpiry(ctx, key, value, ttl, ttl)
+}
+
+func (c *RedisCache) SetWithExpiry(ctx context.Context, key string, value interface{}, soft, hard time.Duration) error {
+	data, err := encode(value, soft)
 	if err != nil {
-		return fmt.Errorf("marshaling data: %w", err)
+		return err
 	}
 
-	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+	if err := c.client.Set(ctx, key, data, hard).Err(); err != nil {
 		return fmt.Errorf("redis set: %w", err)
 	}
 
 	return nil
 }
 
+// unlockScript deletes a lock only if it is still held by the given token
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, false, fmt.Errorf("generating lock token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	acquired, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("redis lock: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = unlockScript.Run(ctx, c.client, []string{key}, token).Err()
+	}
+	return unlock, true, nil
+}
+
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	if err := c.client.Del(ctx, key).Err(); err != nil {
 		return fmt.Errorf("redis delete: %w", err)
@@ -106,15 +239,32 @@ func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+func (c *RedisCache) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := c.client.Scan(ctx, 0, globEscaper.Replace(prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return fmt.Errorf("redis delete: %w", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("redis scan: %w", err)
+	}
+	return nil
+}
+
+// globEscaper escapes the glob pattern characters of a Redis key
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
 func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
 
 // MemoryCache implements Cache using in-memory storage (fallback)
 type MemoryCache struct {
-	data map[string]cacheEntry
-	ttl  time.Duration
-	mu   sync.RWMutex
+	data    map[string]cacheEntry
+	ttl     time.Duration
+	hardTTL time.Duration
+	mu      sync.RWMutex
 }
 
 type cacheEntry struct {
@@ -123,46 +273,56 @@ type cacheEntry struct {
 }
 
 // NewMemoryCache creates a new in-memory cache
-func NewMemoryCache(ttl time.Duration) *MemoryCache {
+func NewMemoryCache(ttl, hardTTL time.Duration) *MemoryCache {
 	return &MemoryCache{
-		data: make(map[string]cacheEntry),
-		ttl:  ttl,
+		data:    make(map[string]cacheEntry),
+		ttl:     ttl,
+		hardTTL: hardTTL,
 	}
 }
 
-func (c *MemoryCache) Get(_ context.Context, key string, dest interface{}) error {
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	_, err := c.GetEntry(ctx, key, dest)
+	return err
+}
+
+func (c *MemoryCache) GetEntry(_ context.Context, key string, dest interface{}) (*Entry, error) {
 	c.mu.RLock()
 	entry, ok := c.data[key]
 	c.mu.RUnlock()
 
 	if !ok {
-		return ErrCacheMiss
+		return nil, ErrCacheMiss
 	}
 
 	if time.Now().After(entry.expiresAt) {
 		c.mu.Lock()
 		delete(c.data, key)
 		c.mu.Unlock()
-		return ErrCacheMiss
+		return nil, ErrCacheMiss
 	}
 
-	if err := json.Unmarshal(entry.data, dest); err != nil {
-		return fmt.Errorf("unmarshaling cached data: %w", err)
-	}
+	return decode(entry.data, dest)
+}
 
-	return nil
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}) error {
+	return c.SetWithExpiry(ctx, key, value, c.ttl, c.hardTTL)
 }
 
-func (c *MemoryCache) Set(_ context.Context, key string, value interface{}) error {
-	data, err := json.Marshal(value)
+func (c *MemoryCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithExpiry(ctx, key, value, ttl, ttl)
+}
+
+func (c *MemoryCache) SetWithExpiry(_ context.Context, key string, value interface{}, soft, hard time.Duration) error {
+	data, err := encode(value, soft)
 	if err != nil {
-		return fmt.Errorf("marshaling data: %w", err)
+		return err
 	}
 
 	c.mu.Lock()
 	c.data[key] = cacheEntry{
 		data:      data,
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: time.Now().Add(hard),
 	}
 	c.mu.Unlock()
 
@@ -176,6 +336,17 @@ func (c *MemoryCache) Delete(_ context.Context, key string) error {
 	return nil
 }
 
+func (c *MemoryCache) DeletePrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	for key := range c.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.data, key)
+		}
+	}
+	c.mu.Unlock()
+	return nil
+}
+
 func (c *MemoryCache) Close() error {
 	return nil
 }
@@ -190,12 +361,12 @@ func New(cfg Config) (Cache, error) {
 		cache, err := NewRedisCache(cfg)
 		if err != nil {
 			fmt.Printf("Warning: Failed to connect to Redis (%v), using memory cache\n", err)
-			return NewMemoryCache(cfg.TTL), nil
+			return NewMemoryCache(cfg.TTL, cfg.HardTTL), nil
 		}
 		fmt.Println("Using Redis cache")
 		return cache, nil
 	}
 
 	fmt.Println("Using memory cache")
-	return NewMemoryCache(cfg.TTL), nil
+	return NewMemoryCache(cfg.TTL, cfg.HardTTL), nil
 }
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+// Store records every version and build ever observed upstream, so items
+// that upstream stops listing (or deletes) can still be served
+type Store interface {
+	// RecordVersions upserts versions of a category
+	RecordVersions(ctx context.Context, categoryID string, versions []models.Version) error
+
+	// RecordBuilds upserts builds of a category version
+	RecordBuilds(ctx context.Context, categoryID, version string, builds []models.Build) error
+
+	// Versions returns every recorded version of a category, most recently
+	// first seen first. Callers sort them by version if needed.
+	Versions(ctx context.Context, categoryID string) ([]models.Version, error)
+
+	// Builds returns every recorded build of a category version, newest first
+	Builds(ctx context.Context, categoryID, version string) ([]models.Build, error)
+
+	// Build returns the recorded build addressed by the given identifier
+	Build(ctx context.Context, categoryID, version string, id models.BuildID) (*models.Build, error)
+
+	// RecordChecksum upserts a checksum computed for a download URL
+	RecordChecksum(ctx context.Context, categoryID, version string, c Checksum) error
+
+	// Checksums returns the checksums computed for download URLs of a category version
+	Checksums(ctx context.Context, categoryID, version string) ([]Checksum, error)
+
+	// VersionStates returns the recorded sync states of a category's versions
+	VersionStates(ctx context.Context, categoryID string) (map[string]models.VersionState, error)
+
+	// RecordVersionStates upserts sync states of a category's versions
+	RecordVersionStates(ctx context.Context, categoryID string, states map[string]models.VersionState) error
+
+	Close() error
+}
+
+// Checksum is the SHA-256 and size of a download whose upstream does not
+// report them, computed when it was first downloaded. It is keyed by the
+// upstream URL, since the same build can point at different content over time.
+type Checksum struct {
+	URL    string
+	SHA256 string
+	Size   int64
+}
+
+// ErrNotFound is returned when an item was never recorded
+var ErrNotFound = fmt.Errorf("not found in catalog")
+
+// Config holds catalog database configuration
+type Config struct {
+	Enabled bool
+	// DatabaseURL is a SQLite file path, or a postgres:// URL to use Postgres
+	DatabaseURL string
+}
+
+// DefaultConfig returns default catalog configuration from environment
+func DefaultConfig() Config {
+	databaseURL := os.Getenv("CATALOG_DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = "catalog.db"
+	}
+
+	return Config{
+		Enabled:     os.Getenv("CATALOG_ENABLED") != "false",
+		DatabaseURL: databaseURL,
+	}
+}
+
+// New opens the catalog database based on configuration
+// Returns nil if the catalog is disabled
+func New(cfg Config) (Store, error) {
+	if !cfg.Enabled {
+		fmt.Println("Catalog database disabled")
+		return nil, nil
+	}
+
+	if strings.HasPrefix(cfg.DatabaseURL, "postgres://") || strings.HasPrefix(cfg.DatabaseURL, "postgresql://") {
+		store, err := NewSQLStore(dialectPostgres, cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println("Using Postgres catalog database")
+		return store, nil
+	}
+
+	store, err := NewSQLStore(dialectSQLite, cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Using SQLite catalog database (%s)\n", cfg.DatabaseURL)
+	return store, nil
+}
@@ -0,0 +1,396 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Postgres driver
+	_ "github.com/mattn/go-sqlite3"    // SQLite driver
+)
+
+// dialect describes the differences between the supported SQL databases
+type dialect struct {
+	driver string
+	// positional reports whether placeholders are $1, $2, ... instead of ?
+	positional bool
+}
+
+var (
+	dialectSQLite   = dialect{driver: "sqlite3"}
+	dialectPostgres = dialect{driver: "pgx", positional: true}
+)
+
+// schema is valid for both SQLite and Postgres. Timestamps are Unix seconds.
+const schema = `
+CREATE TABLE IF NOT EXISTS versions (
+	category   TEXT   NOT NULL,
+	id         TEXT   NOT NULL,
+	data       TEXT   NOT NULL,
+	first_seen BIGINT NOT NULL,
+	last_seen  BIGINT NOT NULL,
+	PRIMARY KEY (category, id)
+);
+
+CREATE TABLE IF NOT EXISTS builds (
+	category   TEXT   NOT NULL,
+	version    TEXT   NOT NULL,
+	build      TEXT   NOT NULL,
+	number     BIGINT NOT NULL,
+	sha256     TEXT   NOT NULL DEFAULT '',
+	data       TEXT   NOT NULL,
+	first_seen BIGINT NOT NULL,
+	last_seen  BIGINT NOT NULL,
+	PRIMARY KEY (category, version, build)
+);
+
+CREATE TABLE IF NOT EXISTS download_checksums (
+	category TEXT   NOT NULL,
+	version  TEXT   NOT NULL,
+	url      TEXT   NOT NULL,
+	sha256   TEXT   NOT NULL,
+	size     BIGINT NOT NULL,
+	computed BIGINT NOT NULL,
+	PRIMARY KEY (category, version, url)
+);
+
+CREATE TABLE IF NOT EXISTS version_states (
+	category TEXT   NOT NULL,
+	version  TEXT   NOT NULL,
+	data     TEXT   NOT NULL,
+	updated  BIGINT NOT NULL,
+	PRIMARY KEY (category, version)
+);
+`
+
+// SQLStore implements Store using SQLite or Postgres
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewSQLStore opens the database and creates the schema if needed
+func NewSQLStore(d dialect, dsn string) (*SQLStore, error) {
+	if d == dialectSQLite && !strings.Contains(dsn, "?") {
+		// Wait for concurrent writers instead of failing with "database is locked"
+		dsn += "?_busy_timeout=5000&_journal_mode=WAL"
+	}
+
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening catalog database: %w", err)
+	}
+	if d == dialectSQLite {
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating catalog schema: %w", err)
+	}
+
+	return &SQLStore{db: db, dialect: d}, nil
+}
+
+// query rewrites ? placeholders for dialects with positional placeholders
+func (s *SQLStore) query(q string) string {
+	if !s.dialect.positional {
+		return q
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) RecordVersions(ctx context.Context, categoryID string, versions []models.Version) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, s.query(`
+		INSERT INTO versions (category, id, data, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (category, id) DO UPDATE SET
+			data = excluded.data,
+			last_seen = excluded.last_seen`))
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	now := time.Now().Unix()
+	for _, v := range versions {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshaling version: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, categoryID, v.ID, string(data), now, now); err != nil {
+			return fmt.Errorf("recording version %s: %w", v.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) RecordBuilds(ctx context.Context, categoryID, version string, builds []models.Build) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Hashes are kept once observed, even if upstream stops reporting them
+	stmt, err := tx.PrepareContext(ctx, s.query(`
+		INSERT INTO builds (category, version, build, number, sha256, data, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (category, version, build) DO UPDATE SET
+			number = excluded.number,
+			sha256 = CASE WHEN excluded.sha256 <> '' THEN excluded.sha256 ELSE builds.sha256 END,
+			data = excluded.data,
+			last_seen = excluded.last_seen`))
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	now := time.Now().Unix()
+	for _, b := range builds {
+		b.FirstSeen = nil
+		data, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("marshaling build: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, categoryID, version, buildKey(b), b.Number, buildSHA256(b), string(data), now, now); err != nil {
+			return fmt.Errorf("recording build %s: %w", buildKey(b), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) Versions(ctx context.Context, categoryID string) ([]models.Version, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`
+		SELECT data FROM versions
+		WHERE category = ?
+		ORDER BY first_seen DESC, id DESC`), categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("querying versions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var versions []models.Version
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scanning version: %w", err)
+		}
+
+		var v models.Version
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			return nil, fmt.Errorf("unmarshaling version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (s *SQLStore) Builds(ctx context.Context, categoryID, version string) ([]models.Build, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`
+		SELECT data, sha256, first_seen FROM builds
+		WHERE category = ? AND version = ?
+		ORDER BY number DESC, first_seen DESC`), categoryID, version)
+	if err != nil {
+		return nil, fmt.Errorf("querying builds: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var builds []models.Build
+	for rows.Next() {
+		var data, sha256 string
+		var firstSeen int64
+		if err := rows.Scan(&data, &sha256, &firstSeen); err != nil {
+			return nil, fmt.Errorf("scanning build: %w", err)
+		}
+
+		var b models.Build
+		if err := json.Unmarshal([]byte(data), &b); err != nil {
+			return nil, fmt.Errorf("unmarshaling build: %w", err)
+		}
+
+		seen := time.Unix(firstSeen, 0).UTC()
+		b.FirstSeen = &seen
+		if sha256 != "" && len(b.Downloads) > 0 && b.Downloads[0].SHA256 == "" {
+			b.Downloads[0].SHA256 = sha256
+		}
+		builds = append(builds, b)
+	}
+
+	return builds, rows.Err()
+}
+
+func (s *SQLStore) Build(ctx context.Context, categoryID, version string, id models.BuildID) (*models.Build, error) {
+	builds, err := s.Builds(ctx, categoryID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := models.FindBuild(builds, id); ok {
+		return b, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *SQLStore) RecordChecksum(ctx context.Context, categoryID, version string, c Checksum) error {
+	_, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO download_checksums (category, version, url, sha256, size, computed)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (category, version, url) DO UPDATE SET
+			sha256 = excluded.sha256,
+			size = excluded.size,
+			computed = excluded.computed`),
+		categoryID, version, c.URL, c.SHA256, c.Size, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("recording checksum of %s: %w", c.URL, err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Checksums(ctx context.Context, categoryID, version string) ([]Checksum, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`
+		SELECT url, sha256, size FROM download_checksums
+		WHERE category = ? AND version = ?`), categoryID, version)
+	if err != nil {
+		return nil, fmt.Errorf("querying checksums: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var checksums []Checksum
+	for rows.Next() {
+		var c Checksum
+		if err := rows.Scan(&c.URL, &c.SHA256, &c.Size); err != nil {
+			return nil, fmt.Errorf("scanning checksum: %w", err)
+		}
+		checksums = append(checksums, c)
+	}
+
+	return checksums, rows.Err()
+}
+
+func (s *SQLStore) VersionStates(ctx context.Context, categoryID string) (map[string]models.VersionState, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`
+		SELECT version, data FROM version_states
+		WHERE category = ?`), categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("querying version states: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	states := make(map[string]models.VersionState)
+	for rows.Next() {
+		var version, data string
+		if err := rows.Scan(&version, &data); err != nil {
+			return nil, fmt.Errorf("scanning version state: %w", err)
+		}
+
+		var state models.VersionState
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			return nil, fmt.Errorf("unmarshaling version state: %w", err)
+		}
+		states[version] = state
+	}
+
+	return states, rows.Err()
+}
+
+func (s *SQLStore) RecordVersionStates(ctx context.Context, categoryID string, states map[string]models.VersionState) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, s.query(`
+		INSERT INTO version_states (category, version, data, updated)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (category, version) DO UPDATE SET
+			data = excluded.data,
+			updated = excluded.updated`))
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	now := time.Now().Unix()
+	for version, state := range states {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("marshaling version state: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, categoryID, version, string(data), now); err != nil {
+			return fmt.Errorf("recording version state %s: %w", version, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// buildKey returns the identifier a build is recorded under
+func buildKey(b models.Build) string {
+	if b.ID != "" {
+		return b.ID.String()
+	}
+	return strconv.Itoa(b.Number)
+}
+
+// buildSHA256 returns the upstream SHA-256 of a build's primary download, if known
+func buildSHA256(b models.Build) string {
+	if len(b.Downloads) == 0 || b.Downloads[0].SHA256Computed {
+		return ""
+	}
+	return b.Downloads[0].SHA256
+}
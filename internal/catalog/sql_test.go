@@ -0,0 +1,153 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+func newTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	s, err := NewSQLStore(dialectSQLite, filepath.Join(t.TempDir(), "catalog.db"))
+	if err != nil {
+		t.Fatalf("NewSQLStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSQLStoreChecksums(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	const url = "https://api.purpurmc.org/v2/purpur/1.21/2300/download"
+	for _, c := range []Checksum{
+		{URL: url, SHA256: "old", Size: 1},
+		{URL: url, SHA256: "new", Size: 2}, // Upserted by URL
+		{URL: url + "?other", SHA256: "other", Size: 3},
+	} {
+		if err := s.RecordChecksum(ctx, "purpur", "1.21", c); err != nil {
+			t.Fatalf("RecordChecksum() error = %v", err)
+		}
+	}
+	if err := s.RecordChecksum(ctx, "purpur", "1.20", Checksum{URL: url, SHA256: "elsewhere"}); err != nil {
+		t.Fatalf("RecordChecksum() error = %v", err)
+	}
+
+	checksums, err := s.Checksums(ctx, "purpur", "1.21")
+	if err != nil {
+		t.Fatalf("Checksums() error = %v", err)
+	}
+	byURL := make(map[string]Checksum)
+	for _, c := range checksums {
+		byURL[c.URL] = c
+	}
+	if len(byURL) != 2 {
+		t.Fatalf("Checksums() = %+v, want 2 URLs", checksums)
+	}
+	if c := byURL[url]; c.SHA256 != "new" || c.Size != 2 {
+		t.Errorf("checksum of %s = %+v, want the last recorded one", url, c)
+	}
+}
+
+func TestSQLStoreVersions(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.RecordVersions(ctx, "paper", []models.Version{{ID: "1.21"}}); err != nil {
+		t.Fatalf("RecordVersions() error = %v", err)
+	}
+	// Recording a version again updates it
+	if err := s.RecordVersions(ctx, "paper", []models.Version{{ID: "1.21", Stable: true}}); err != nil {
+		t.Fatalf("RecordVersions() error = %v", err)
+	}
+	if err := s.RecordVersions(ctx, "purpur", []models.Version{{ID: "1.20"}}); err != nil {
+		t.Fatalf("RecordVersions() error = %v", err)
+	}
+
+	versions, err := s.Versions(ctx, "paper")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].ID != "1.21" || !versions[0].Stable {
+		t.Errorf("Versions() = %+v, want the updated 1.21", versions)
+	}
+}
+
+func TestSQLStoreBuilds(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	builds := []models.Build{
+		{Number: 124, Version: "1.21", Downloads: []models.Download{{Name: "paper-124.jar", SHA256: "hash124"}}},
+		{Number: 123, Version: "1.21", Downloads: []models.Download{{Name: "paper-123.jar", SHA256: "hash123"}}},
+	}
+	if err := s.RecordBuilds(ctx, "paper", "1.21", builds); err != nil {
+		t.Fatalf("RecordBuilds() error = %v", err)
+	}
+
+	// The upstream stops reporting the hash of build 123; the recorded one is kept
+	builds[1].Downloads[0].SHA256 = ""
+	if err := s.RecordBuilds(ctx, "paper", "1.21", builds[1:]); err != nil {
+		t.Fatalf("RecordBuilds() error = %v", err)
+	}
+
+	got, err := s.Builds(ctx, "paper", "1.21")
+	if err != nil {
+		t.Fatalf("Builds() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Number != 124 || got[1].Number != 123 {
+		t.Fatalf("Builds() = %+v, want builds 124 and 123", got)
+	}
+	if got[1].FirstSeen == nil {
+		t.Error("FirstSeen not set")
+	}
+	if sha := got[1].Downloads[0].SHA256; sha != "hash123" {
+		t.Errorf("SHA256 = %q, want the recorded hash123", sha)
+	}
+
+	b, err := s.Build(ctx, "paper", "1.21", "123")
+	if err != nil || b.Number != 123 {
+		t.Errorf("Build(123) = %+v, %v, want build 123", b, err)
+	}
+	if _, err := s.Build(ctx, "paper", "1.21", "99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Build(99) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLStoreVersionStates(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	checked := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	states := map[string]models.VersionState{
+		"1.21": {Fingerprint: "a1", HasStable: true, Java: 21, CheckedAt: checked},
+		"1.20": {Fingerprint: "b2", CheckedAt: checked},
+	}
+	if err := s.RecordVersionStates(ctx, "paper", states); err != nil {
+		t.Fatalf("RecordVersionStates() error = %v", err)
+	}
+	// Only changed versions are recorded again
+	if err := s.RecordVersionStates(ctx, "paper", map[string]models.VersionState{"1.21": {Fingerprint: "c3", CheckedAt: checked}}); err != nil {
+		t.Fatalf("RecordVersionStates() error = %v", err)
+	}
+
+	got, err := s.VersionStates(ctx, "paper")
+	if err != nil {
+		t.Fatalf("VersionStates() error = %v", err)
+	}
+	if len(got) != 2 || got["1.21"].Fingerprint != "c3" || got["1.20"].Fingerprint != "b2" {
+		t.Errorf("VersionStates() = %+v, want 1.21 updated and 1.20 kept", got)
+	}
+	if !got["1.20"].CheckedAt.Equal(checked) {
+		t.Errorf("CheckedAt = %v, want %v", got["1.20"].CheckedAt, checked)
+	}
+
+	if other, _ := s.VersionStates(ctx, "purpur"); len(other) != 0 {
+		t.Errorf("VersionStates(purpur) = %+v, want none", other)
+	}
+}
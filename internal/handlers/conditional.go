@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeJSON writes a successful API response with an ETag over its body.
+// Clients revalidating with If-None-Match get 304 Not Modified if nothing
+// changed. No Last-Modified is sent: the time data was fetched changes on
+// every refetch even when the body does not.
+func writeJSON(c *gin.Context, resp APIResponse) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Error:   "failed to encode response",
+		})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+
+	if notModified(c.Request, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// notModified evaluates If-None-Match against the current ETag of a
+// resource (RFC 9110 section 13.2.2)
+func notModified(r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	inm := r.Header.Get("If-None-Match")
+	return inm != "" && etag != "" && etagMatches(inm, etag)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// weak comparison
+func etagMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		want   bool
+	}{
+		{"exact", `"abc"`, `"abc"`, true},
+		{"different", `"abc"`, `"abd"`, false},
+		{"wildcard", `*`, `"abc"`, true},
+		{"wildcard with spaces", ` * `, `"abc"`, true},
+		{"list", `"x", "abc"`, `"abc"`, true},
+		{"list without match", `"x","y"`, `"abc"`, false},
+		{"weak header", `W/"abc"`, `"abc"`, true},
+		{"weak etag", `"abc"`, `W/"abc"`, true},
+		{"unquoted", `abc`, `"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, tt.etag); got != tt.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		etag    string
+		want    bool
+	}{
+		{
+			name:   "no conditions",
+			method: http.MethodGet,
+			etag:   `"abc"`,
+			want:   false,
+		},
+		{
+			name:    "etag match",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "etag match on HEAD",
+			method:  http.MethodHead,
+			headers: map[string]string{"If-None-Match": `"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "etag match ignored for POST",
+			method:  http.MethodPost,
+			headers: map[string]string{"If-None-Match": `"abc"`},
+			etag:    `"abc"`,
+			want:    false,
+		},
+		{
+			name:    "etag changed",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"old"`},
+			etag:    `"abc"`,
+			want:    false,
+		},
+		{
+			name:    "unknown etag",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `*`},
+			want:    false,
+		},
+		{
+			name:    "If-Modified-Since is not evaluated",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-Modified-Since": time.Now().UTC().Format(http.TimeFormat)},
+			etag:    `"abc"`,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/categories", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := notModified(r, tt.etag); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/artifacts"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// downloadChunkSize is the size of the chunks streamed to clients
+const downloadChunkSize = 32 << 10
+
+// artifactWarmTimeout bounds storing an artifact in the background
+const artifactWarmTimeout = 10 * time.Minute
+
+// downloadETag returns a strong ETag derived from the download's hash, or ""
+// if the hash is unknown. Jars never change for a given hash, so the ETag
+// stays valid across the artifact store and upstream.
+func downloadETag(download models.Download) string {
+	key, ok := artifacts.Key(download)
+	if !ok {
+		return ""
+	}
+	return `"` + strings.Replace(key, "/", "-", 1) + `"`
+}
+
+// fetchDownload requests a download from upstream, forwarding a Range header if set
+func (h *Handler) fetchDownload(ctx context.Context, method, url, rangeHeader string) (*http.Response, error) {
+	return h.downloader.Fetch(ctx, method, url, rangeHeader)
+}
+
+// storeArtifact downloads an artifact from upstream into the artifact store
+// without streaming it to the client
+func (h *Handler) storeArtifact(ctx context.Context, download models.Download, key string) error {
+	verifier, ok := artifacts.NewVerifier(download)
+	if !ok {
+		return fmt.Errorf("download has no known hash")
+	}
+
+	resp, err := h.fetchDownload(ctx, http.MethodGet, download.UpstreamURL, "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+
+	w, err := h.artifacts.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := copyVerified(w, resp.Body, verifier); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Commit()
+}
+
+// warmArtifact stores an artifact in the background unless it is already
+// being stored
+func (h *Handler) warmArtifact(categoryID, version, build string, download models.Download, key string) {
+	if _, busy := h.warming.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer h.warming.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), artifactWarmTimeout)
+		defer cancel()
+
+		if err := h.storeArtifact(ctx, download, key); err != nil {
+			var mismatch *artifacts.MismatchError
+			if errors.As(err, &mismatch) {
+				h.recordIncident(categoryID, version, build, mismatch)
+				return
+			}
+			log.Printf("Warning: Failed to store artifact %s: %v", key, err)
+		}
+	}()
+}
+
+// serveArtifact serves a stored artifact, honouring Range and If-Range, or
+// redirects to a presigned mirror URL if enabled
+func (h *Handler) serveArtifact(c *gin.Context, categoryID string, obj *artifacts.Object, key, filename string, download models.Download) {
+	// Let the client download directly from the mirror if enabled
+	if u, ok := h.presignArtifact(c, key, filename); ok {
+		h.recordDownload(c, categoryID, downloadViaMirror)
+		c.Redirect(http.StatusFound, u)
+		return
+	}
+
+	c.Header("Content-Type", artifacts.ContentType(filename))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	setChecksumHeaders(c, download)
+	h.recordDownload(c, categoryID, downloadViaCache)
+	http.ServeContent(c.Writer, c.Request, filename, obj.ModTime, obj)
+}
+
+// presignArtifact returns a presigned mirror URL for a stored artifact, if
+// the artifact store supports and enables presigned redirects
+func (h *Handler) presignArtifact(c *gin.Context, key, filename string) (string, bool) {
+	presigner, ok := h.artifacts.(artifacts.Presigner)
+	if !ok {
+		return "", false
+	}
+
+	u, ok, err := presigner.PresignGet(c.Request.Context(), key, filename)
+	if err != nil {
+		log.Printf("Warning: Failed to presign artifact %s: %v", key, err)
+		return "", false
+	}
+	return u, ok
+}
+
+// downloadMode returns the download mode of a request: the ?mode query
+// parameter if valid, or else the configured default
+func (h *Handler) downloadMode(c *gin.Context) string {
+	switch mode := c.Query("mode"); mode {
+	case DownloadModeProxy, DownloadModeRedirect:
+		return mode
+	default:
+		return h.config.DownloadMode
+	}
+}
+
+// redirectDownload redirects the client to the mirror URL of a stored
+// artifact if presigned redirects are enabled, and to the upstream URL
+// otherwise. The checksum headers are kept so clients can still verify it.
+func (h *Handler) redirectDownload(c *gin.Context, categoryID string, download models.Download, key string, cacheable bool, filename string) {
+	setChecksumHeaders(c, download)
+
+	if cacheable {
+		if obj, err := h.artifacts.Get(c.Request.Context(), key); err == nil {
+			_ = obj.Close()
+			if u, ok := h.presignArtifact(c, key, filename); ok {
+				h.recordDownload(c, categoryID, downloadViaMirror)
+				c.Redirect(http.StatusFound, u)
+				return
+			}
+		} else if !errors.Is(err, artifacts.ErrNotFound) {
+			log.Printf("Warning: Failed to read artifact %s: %v", key, err)
+		}
+	}
+
+	h.recordDownload(c, categoryID, downloadViaUpstream)
+	c.Redirect(http.StatusFound, download.UpstreamURL)
+}
+
+// headDownload answers a HEAD request for a download with its size,
+// filename and checksums. The size comes from the artifact store or the
+// provider metadata if known, and otherwise from a HEAD request upstream.
+func (h *Handler) headDownload(c *gin.Context, download models.Download, filename, key string, cacheable bool) {
+	ctx := c.Request.Context()
+	size := download.Size
+	acceptRanges := cacheable
+
+	if cacheable {
+		if obj, err := h.artifacts.Get(ctx, key); err == nil {
+			size = obj.Size
+			_ = obj.Close()
+		} else if !errors.Is(err, artifacts.ErrNotFound) {
+			log.Printf("Warning: Failed to read artifact %s: %v", key, err)
+		}
+	}
+
+	if size <= 0 {
+		resp, err := h.fetchDownload(ctx, http.MethodHead, download.UpstreamURL, "")
+		if err != nil {
+			c.JSON(http.StatusBadGateway, APIResponse{
+				Success: false,
+				Error:   "failed to fetch from upstream",
+			})
+			return
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			c.JSON(http.StatusBadGateway, APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("upstream returned status %d", resp.StatusCode),
+			})
+			return
+		}
+		size = resp.ContentLength
+		acceptRanges = acceptRanges || resp.Header.Get("Accept-Ranges") == "bytes"
+	}
+
+	c.Header("Content-Type", artifacts.ContentType(filename))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	setChecksumHeaders(c, download)
+	if acceptRanges {
+		c.Header("Accept-Ranges", "bytes")
+	}
+	if size > 0 {
+		c.Header("Content-Length", strconv.FormatInt(size, 10))
+	}
+	c.Status(http.StatusOK)
+}
+
+// How downloads are delivered, as reported in download stats
+const (
+	downloadViaProxy    = "proxy"    // Streamed from upstream through the API
+	downloadViaCache    = "cache"    // Served from the artifact store
+	downloadViaMirror   = "mirror"   // Redirected to a presigned mirror URL
+	downloadViaUpstream = "upstream" // Redirected to the upstream URL
+)
+
+// downloadStats counts downloads per category and delivery method
+type downloadStats struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int64
+}
+
+func newDownloadStats() *downloadStats {
+	return &downloadStats{counts: make(map[string]map[string]int64)}
+}
+
+func (s *downloadStats) record(categoryID, via string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[categoryID] == nil {
+		s.counts[categoryID] = make(map[string]int64)
+	}
+	s.counts[categoryID][via]++
+}
+
+// snapshot returns a copy of the counts
+func (s *downloadStats) snapshot() map[string]map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]map[string]int64, len(s.counts))
+	for categoryID, byVia := range s.counts {
+		counts[categoryID] = make(map[string]int64, len(byVia))
+		for via, n := range byVia {
+			counts[categoryID][via] = n
+		}
+	}
+	return counts
+}
+
+// recordDownload records a download event. Requests resuming a download
+// with Range are not counted again.
+func (h *Handler) recordDownload(c *gin.Context, categoryID, via string) {
+	if c.GetHeader("Range") != "" {
+		return
+	}
+	h.downloads.record(categoryID, via)
+}
+
+// checksumWriter computes the SHA-256 and size of a download
+type checksumWriter struct {
+	hash hash.Hash
+	size int64
+}
+
+func newChecksumWriter() *checksumWriter {
+	return &checksumWriter{hash: sha256.New()}
+}
+
+func (w *checksumWriter) Write(p []byte) (int, error) {
+	w.size += int64(len(p))
+	return w.hash.Write(p)
+}
+
+// storeWriter tees a download into the artifact store. The store is
+// optional: a failed write only marks the artifact failed, so the download
+// carries on for the client.
+type storeWriter struct {
+	w   artifacts.Writer
+	err error
+}
+
+func (s *storeWriter) Write(p []byte) (int, error) {
+	if s.err == nil {
+		if _, err := s.w.Write(p); err != nil {
+			s.err = err
+		}
+	}
+	return len(p), nil
+}
+
+// recordChecksum persists the checksum computed while streaming a complete
+// download, if one was computed
+func (h *Handler) recordChecksum(c *gin.Context, categoryID, version string, build *models.Build, download models.Download, checksum *checksumWriter) {
+	if checksum == nil {
+		return
+	}
+
+	sum := hex.EncodeToString(checksum.hash.Sum(nil))
+	h.svc.RecordChecksum(context.WithoutCancel(c.Request.Context()), categoryID, version, build, download, sum, checksum.size)
+}
+
+// recordIncident logs and records a download that failed checksum verification
+func (h *Handler) recordIncident(categoryID, version, build string, mismatch *artifacts.MismatchError) {
+	log.Printf("Error: Download of %s %s build %s failed verification: %v", categoryID, version, build, mismatch)
+	h.incidents.Record(artifacts.Incident{
+		Time:      time.Now(),
+		Category:  categoryID,
+		Version:   version,
+		Build:     build,
+		Algorithm: mismatch.Algorithm,
+		Expected:  mismatch.Expected,
+		Actual:    mismatch.Actual,
+	})
+}
+
+// setChecksumHeaders exposes the expected hashes of a download so clients
+// can verify it: Digest (RFC 3230, base64) and X-Checksum-* (hex)
+func setChecksumHeaders(c *gin.Context, download models.Download) {
+	var digests []string
+
+	if download.SHA256 != "" {
+		c.Header("X-Checksum-Sha256", strings.ToLower(download.SHA256))
+		if raw, err := hex.DecodeString(download.SHA256); err == nil {
+			digests = append(digests, "sha-256="+base64.StdEncoding.EncodeToString(raw))
+		}
+	}
+	if download.SHA1 != "" {
+		c.Header("X-Checksum-Sha1", strings.ToLower(download.SHA1))
+		if raw, err := hex.DecodeString(download.SHA1); err == nil {
+			digests = append(digests, "sha="+base64.StdEncoding.EncodeToString(raw))
+		}
+	}
+
+	if download.MD5 != "" {
+		c.Header("X-Checksum-Md5", strings.ToLower(download.MD5))
+		if raw, err := hex.DecodeString(download.MD5); err == nil {
+			digests = append(digests, "md5="+base64.StdEncoding.EncodeToString(raw))
+		}
+	}
+
+	if len(digests) > 0 {
+		c.Header("Digest", strings.Join(digests, ","))
+	}
+}
+
+// copyVerified copies src to dst while hashing it. The final chunk is held
+// back until the content is verified, so a corrupted download never
+// completes on the client side. Returns a *artifacts.MismatchError on mismatch.
+func copyVerified(dst io.Writer, src io.Reader, v *artifacts.Verifier) error {
+	buf := make([]byte, downloadChunkSize)
+	pending := make([]byte, 0, downloadChunkSize)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if len(pending) > 0 {
+				if _, err := dst.Write(pending); err != nil {
+					return err
+				}
+			}
+			pending = append(pending[:0], buf[:n]...)
+			_, _ = v.Write(buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := v.Verify(); err != nil {
+		return err
+	}
+
+	_, err := dst.Write(pending)
+	return err
+}
+
+// abortConnection closes the client connection without completing the
+// response, so the client sees a failed download rather than a short one
+func abortConnection(c *gin.Context) {
+	c.Abort()
+
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		// Not hijackable (e.g. HTTP/2): the response is left short of its
+		// Content-Length (or final chunk), which clients also treat as failed
+		return
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/artifacts"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCopyVerified(t *testing.T) {
+	content := strings.Repeat("jar", downloadChunkSize) // Several chunks
+
+	tests := []struct {
+		name    string
+		src     io.Reader
+		sha256  string
+		wantErr error
+		wantOut string
+	}{
+		{
+			name:    "match",
+			src:     strings.NewReader(content),
+			sha256:  sha256Hex(content),
+			wantOut: content,
+		},
+		{
+			name:    "match with short reads",
+			src:     iotest.OneByteReader(strings.NewReader("abc")),
+			sha256:  sha256Hex("abc"),
+			wantOut: "abc",
+		},
+		{
+			name:    "empty",
+			src:     strings.NewReader(""),
+			sha256:  sha256Hex(""),
+			wantOut: "",
+		},
+		{
+			name:    "mismatch holds back the final chunk",
+			src:     iotest.OneByteReader(strings.NewReader("abc")),
+			sha256:  sha256Hex("abd"),
+			wantErr: artifacts.ErrChecksumMismatch,
+			wantOut: "ab",
+		},
+		{
+			name:    "read error",
+			src:     io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(io.ErrUnexpectedEOF)),
+			sha256:  sha256Hex("ab"),
+			wantErr: io.ErrUnexpectedEOF,
+			wantOut: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := artifacts.NewVerifier(models.Download{SHA256: tt.sha256})
+			if !ok {
+				t.Fatal("NewVerifier: download not verifiable")
+			}
+
+			var dst bytes.Buffer
+			err := copyVerified(&dst, tt.src, v)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("copyVerified() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := dst.String(); got != tt.wantOut {
+				t.Errorf("copyVerified() wrote %d bytes, want %d", len(got), len(tt.wantOut))
+			}
+		})
+	}
+}
+
+func TestCopyVerifiedMismatchError(t *testing.T) {
+	v, _ := artifacts.NewVerifier(models.Download{SHA256: sha256Hex("expected")})
+
+	err := copyVerified(io.Discard, strings.NewReader("actual"), v)
+
+	var mismatch *artifacts.MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("copyVerified() error = %v, want *artifacts.MismatchError", err)
+	}
+	if mismatch.Algorithm != "sha256" || mismatch.Actual != sha256Hex("actual") {
+		t.Errorf("mismatch = %+v", mismatch)
+	}
+}
+
+// failingArtifact is an artifact writer whose writes fail after limit bytes
+type failingArtifact struct {
+	limit   int
+	written int
+}
+
+func (f *failingArtifact) Write(p []byte) (int, error) {
+	if f.written+len(p) > f.limit {
+		return 0, errors.New("no space left on device")
+	}
+	f.written += len(p)
+	return len(p), nil
+}
+
+func (f *failingArtifact) Commit() error { return nil }
+func (f *failingArtifact) Abort()        {}
+
+func TestStoreWriterFailureKeepsStreaming(t *testing.T) {
+	content := strings.Repeat("jar", downloadChunkSize)
+	v, _ := artifacts.NewVerifier(models.Download{SHA256: sha256Hex(content)})
+
+	artifact := &failingArtifact{limit: downloadChunkSize}
+	store := &storeWriter{w: artifact}
+
+	var client bytes.Buffer
+	if err := copyVerified(io.MultiWriter(&client, store), strings.NewReader(content), v); err != nil {
+		t.Fatalf("copyVerified() error = %v, want nil", err)
+	}
+	if client.String() != content {
+		t.Errorf("client received %d bytes, want %d", client.Len(), len(content))
+	}
+	if store.err == nil {
+		t.Error("store.err = nil, want the artifact write error")
+	}
+}
+
+// readSeekNopCloser adds a no-op Close to an io.ReadSeeker
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+func TestServeArtifactRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const content = "0123456789"
+
+	tests := []struct {
+		name       string
+		rangeHdr   string
+		ifRange    string
+		wantStatus int
+		wantBody   string
+		wantCount  int64
+	}{
+		{"full download", "", "", http.StatusOK, content, 1},
+		{"resumed download", "bytes=4-", "", http.StatusPartialContent, "456789", 0},
+		{"matching If-Range", "bytes=0-1", `"abc"`, http.StatusPartialContent, "01", 0},
+		{"changed content ignores range", "bytes=0-1", `"old"`, http.StatusOK, content, 0},
+		{"unsatisfiable range", "bytes=20-", "", http.StatusRequestedRangeNotSatisfiable, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{downloads: newDownloadStats()}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/download", nil)
+			if tt.rangeHdr != "" {
+				c.Request.Header.Set("Range", tt.rangeHdr)
+			}
+			if tt.ifRange != "" {
+				c.Request.Header.Set("If-Range", tt.ifRange)
+			}
+			c.Header("ETag", `"abc"`)
+
+			obj := &artifacts.Object{ReadSeekCloser: readSeekNopCloser{strings.NewReader(content)}, Size: int64(len(content))}
+			h.serveArtifact(c, "paper", obj, "sha256/abc", "paper.jar", models.Download{})
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := h.downloads.snapshot()["paper"][downloadViaCache]; got != tt.wantCount {
+				t.Errorf("recorded %d downloads, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
@@ -1,36 +1,127 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/artifacts"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/providers"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/scheduler"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/service"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 	"github.com/gin-gonic/gin"
 )
 
 // Handler contains all HTTP handlers
 type Handler struct {
 	svc        *service.JarsService
-	httpClient *http.Client
+	warmer     *scheduler.Scheduler
+	artifacts  artifacts.Store // Optional; nil disables the artifact cache
+	incidents  *artifacts.IncidentLog
+	downloads  *downloadStats
+	downloader *providers.Downloader
+	config     Config
+
+	warming sync.Map // Artifact keys being stored in the background
+}
+
+// Download modes
+const (
+	DownloadModeProxy    = "proxy"    // Stream jars through the API
+	DownloadModeRedirect = "redirect" // Redirect clients to the upstream or mirror URL
+)
+
+// Config holds handler configuration
+type Config struct {
+	// DownloadMode is the default download mode; clients can override it
+	// per request with ?mode=proxy or ?mode=redirect
+	DownloadMode string
+
+	// Downloader fetches jars from upstream (created from the default
+	// provider configuration if nil)
+	Downloader *providers.Downloader
+}
+
+// DefaultConfig returns default handler configuration from environment
+func DefaultConfig() Config {
+	mode := DownloadModeProxy
+	if os.Getenv("DOWNLOAD_MODE") == DownloadModeRedirect {
+		mode = DownloadModeRedirect
+	}
+
+	return Config{
+		DownloadMode: mode,
+	}
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(svc *service.JarsService) *Handler {
+func NewHandler(svc *service.JarsService, warmer *scheduler.Scheduler, store artifacts.Store, cfg Config) *Handler {
+	downloader := cfg.Downloader
+	if downloader == nil {
+		downloader = providers.NewDownloader(providers.DefaultConfig())
+	}
+
 	return &Handler{
 		svc:        svc,
-		httpClient: &http.Client{},
+		warmer:     warmer,
+		artifacts:  store,
+		incidents:  artifacts.NewIncidentLog(100),
+		downloads:  newDownloadStats(),
+		downloader: downloader,
+		config:     cfg,
 	}
 }
 
+var (
+	// errInvalidBuild is returned when the build parameter is not a valid build identifier
+	errInvalidBuild = errors.New("invalid build identifier")
+
+	// errInvalidInstaller is returned when an installer is selected for a
+	// build that is not paired with one, or the installer is not valid
+	errInvalidInstaller = errors.New("invalid installer selection")
+)
+
 // APIResponse is the standard API response wrapper
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Stale   bool        `json:"stale,omitempty"` // Data served from the last-known-good cache during an upstream outage
+}
+
+// TrackFreshness records whether stale data is served while handling a request
+func (h *Handler) TrackFreshness(c *gin.Context) {
+	ctx, _ := service.WithResponseMeta(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+// respond writes a successful API response, flagging stale data and
+// reporting the age of cached data in the Age header. The response can be
+// revalidated with If-None-Match.
+func (h *Handler) respond(c *gin.Context, data interface{}) {
+	resp := APIResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	if meta := service.ResponseMetaFrom(c.Request.Context()); meta != nil {
+		resp.Stale = meta.Stale()
+		if meta.Cached() {
+			c.Header("Age", strconv.Itoa(int(meta.Age().Seconds())))
+		}
+	}
+
+	writeJSON(c, resp)
 }
 
 // resolveVersion resolves "latest" to the actual latest stable version ID
@@ -45,9 +136,56 @@ func (h *Handler) resolveVersion(c *gin.Context, categoryID, version string) (st
 	return version, nil
 }
 
+// errorStatus maps service and upstream errors to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidBuild), errors.Is(err, errInvalidInstaller), errors.Is(err, service.ErrNoInstallers):
+		return http.StatusBadRequest
+	case errors.Is(err, upstream.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, upstream.ErrUpstreamUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusNotFound
+	}
+}
+
+// resolveBuild resolves a build parameter: "latest", a build number, a
+// semantic build string (e.g. Forge "47.2.0") or a commit hash. The
+// ?installer query parameter pairs the build's loader with another installer.
+func (h *Handler) resolveBuild(c *gin.Context, categoryID, version, buildStr string) (*models.Build, error) {
+	var build *models.Build
+	var err error
+	if buildStr == "latest" {
+		build, err = h.svc.GetLatestBuild(c.Request.Context(), categoryID, version)
+	} else {
+		buildID, parseErr := models.ParseBuildID(buildStr)
+		if parseErr != nil {
+			return nil, errInvalidBuild
+		}
+		build, err = h.svc.GetBuild(c.Request.Context(), categoryID, version, buildID)
+	}
+
+	installer := c.Query("installer")
+	if err != nil || installer == "" || installer == build.Installer {
+		return build, err
+	}
+	if build.Loader == "" || build.Installer == "" {
+		return nil, errInvalidInstaller
+	}
+
+	buildID, err := models.ParseBuildID(build.Loader + "+" + installer)
+	if err != nil {
+		return nil, errInvalidInstaller
+	}
+	return h.svc.GetBuild(c.Request.Context(), categoryID, version, buildID)
+}
+
 // HealthCheck handles health check requests
 func (h *Handler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, APIResponse{
+	writeJSON(c, APIResponse{
 		Success: true,
 		Data: gin.H{
 			"status":  "healthy",
@@ -56,13 +194,25 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	})
 }
 
+// GetStatus handles GET /status
+// Reports the last refresh time and last error of the catalog warmer per
+// category, download counts, and the most recent downloads that failed
+// checksum verification
+func (h *Handler) GetStatus(c *gin.Context) {
+	writeJSON(c, APIResponse{
+		Success: true,
+		Data: gin.H{
+			"warmer":             h.warmer.Status(),
+			"downloads":          h.downloads.snapshot(),
+			"checksum_incidents": h.incidents.Recent(),
+		},
+	})
+}
+
 // GetCategories handles GET /categories
 func (h *Handler) GetCategories(c *gin.Context) {
 	categories := h.svc.GetCategories(c.Request.Context())
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    categories,
-	})
+	h.respond(c, categories)
 }
 
 // GetCategory handles GET /categories/:category
@@ -71,16 +221,29 @@ func (h *Handler) GetCategory(c *gin.Context) {
 
 	category, err := h.svc.GetCategory(c.Request.Context(), categoryID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    category,
-	})
+	h.respond(c, category)
+}
+
+// GetInstallers handles GET /categories/:category/installers
+// Lists the installer versions builds can be paired with (?installer=)
+func (h *Handler) GetInstallers(c *gin.Context) {
+	categoryID := c.Param("category")
+
+	installers, err := h.svc.GetInstallers(c.Request.Context(), categoryID)
+	if err != nil {
+		c.JSON(errorStatus(err), APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	h.respond(c, installers)
 }
 
 // GetVersions handles GET /categories/:category/versions
@@ -132,7 +295,7 @@ func (h *Handler) GetVersions(c *gin.Context) {
 
 	versions, err := h.svc.GetVersionsFiltered(c.Request.Context(), categoryID, opts)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -143,10 +306,7 @@ func (h *Handler) GetVersions(c *gin.Context) {
 		Category: models.Category(categoryID),
 		Versions: versions,
 	}
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    response,
-	})
+	h.respond(c, response)
 }
 
 // GetBuilds handles GET /categories/:category/versions/:version/builds
@@ -159,7 +319,7 @@ func (h *Handler) GetBuilds(c *gin.Context) {
 	// Resolve "latest" to actual version
 	resolvedVersion, err := h.resolveVersion(c, categoryID, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -189,7 +349,7 @@ func (h *Handler) GetBuilds(c *gin.Context) {
 
 	builds, err := h.svc.GetBuildsFiltered(c.Request.Context(), categoryID, resolvedVersion, opts)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -211,10 +371,7 @@ func (h *Handler) GetBuilds(c *gin.Context) {
 		Builds:       builds,
 		LatestStable: latestStable,
 	}
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    response,
-	})
+	h.respond(c, response)
 }
 
 // GetBuild handles GET /categories/:category/versions/:version/builds/:build
@@ -228,44 +385,32 @@ func (h *Handler) GetBuild(c *gin.Context) {
 	// Resolve "latest" to actual version
 	resolvedVersion, err := h.resolveVersion(c, categoryID, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	var build *models.Build
-
-	if buildStr == "latest" {
-		build, err = h.svc.GetLatestBuild(c.Request.Context(), categoryID, resolvedVersion)
-	} else {
-		buildNum, parseErr := strconv.Atoi(buildStr)
-		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, APIResponse{
-				Success: false,
-				Error:   "invalid build number",
-			})
-			return
-		}
-		build, err = h.svc.GetBuild(c.Request.Context(), categoryID, resolvedVersion, buildNum)
-	}
-
+	build, err := h.resolveBuild(c, categoryID, resolvedVersion, buildStr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    build,
-	})
+	h.respond(c, build)
 }
 
 // GetDownload handles GET /categories/:category/versions/:version/builds/:build/download
-// This proxies the download through our API, streaming directly from source to client
+// This proxies the download through our API, streaming directly from source to client.
+// Downloads with a known hash are kept in the artifact store (if enabled) and
+// served from it on later requests, or redirected to a presigned S3 URL.
+// Range requests are served from the artifact store, or forwarded upstream.
+// HEAD requests return the size, filename and checksums without the body.
+// In redirect mode clients are sent to the upstream (or mirror) URL instead.
+// Query params: variant (download kind, e.g. "server:mojmap"; default: primary), mode
 // Note: version can be "latest" to get the latest stable version
 // Note: build can be "latest" to get the latest build
 func (h *Handler) GetDownload(c *gin.Context) {
@@ -276,60 +421,98 @@ func (h *Handler) GetDownload(c *gin.Context) {
 	// Resolve "latest" to actual version
 	resolvedVersion, err := h.resolveVersion(c, categoryID, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	var build *models.Build
-
-	if buildStr == "latest" {
-		build, err = h.svc.GetLatestBuild(c.Request.Context(), categoryID, resolvedVersion)
-	} else {
-		buildNum, parseErr := strconv.Atoi(buildStr)
-		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, APIResponse{
-				Success: false,
-				Error:   "invalid build number",
-			})
-			return
-		}
-		build, err = h.svc.GetBuild(c.Request.Context(), categoryID, resolvedVersion, buildNum)
-	}
-
+	build, err := h.resolveBuild(c, categoryID, resolvedVersion, buildStr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, APIResponse{
+		c.JSON(errorStatus(err), APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	if len(build.Downloads) == 0 || build.Downloads[0].UpstreamURL == "" {
+	variant := c.Query("variant")
+	selected, ok := build.Download(variant)
+	if !ok || selected.UpstreamURL == "" {
+		msg := "no download available"
+		if variant != "" {
+			msg = fmt.Sprintf("download variant %s not found", variant)
+		}
 		c.JSON(http.StatusNotFound, APIResponse{
 			Success: false,
-			Error:   "no download available",
+			Error:   msg,
 		})
 		return
 	}
 
-	download := build.Downloads[0]
+	download := *selected
+	ctx := c.Request.Context()
 
-	// Create request to upstream
-	req, err := http.NewRequestWithContext(c.Request.Context(), "GET", download.UpstreamURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Success: false,
-			Error:   "failed to create download request",
-		})
+	// Determine filename
+	filename := download.Name
+	if filename == "" {
+		filename = fmt.Sprintf("%s-%s-%d.jar", categoryID, resolvedVersion, build.Number)
+	}
+
+	etag := downloadETag(download)
+	if etag != "" {
+		c.Header("ETag", etag)
+		if notModified(c.Request, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	// A range is only honoured while If-Range still matches the content
+	rangeHeader := c.GetHeader("Range")
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != etag {
+		rangeHeader = ""
+	}
+
+	// Serve from the artifact store if stored
+	key, cacheable := artifacts.Key(download)
+	cacheable = cacheable && h.artifacts != nil
+
+	if c.Request.Method == http.MethodHead {
+		h.headDownload(c, download, filename, key, cacheable)
 		return
 	}
-	req.Header.Set("User-Agent", "jarvault/1.0.0 (https://github.com/ServerwaveHost/wave-mc-jars-api)")
 
-	// Execute request
-	resp, err := h.httpClient.Do(req)
+	if h.downloadMode(c) == DownloadModeRedirect {
+		h.redirectDownload(c, categoryID, download, key, cacheable, filename)
+		return
+	}
+
+	// Jars on slow links can take longer than the server's WriteTimeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	if cacheable {
+		obj, err := h.artifacts.Get(ctx, key)
+		if err == nil {
+			defer func() {
+				_ = obj.Close()
+			}()
+			h.serveArtifact(c, categoryID, obj, key, filename, download)
+			return
+		}
+
+		if !errors.Is(err, artifacts.ErrNotFound) {
+			log.Printf("Warning: Failed to read artifact %s: %v", key, err)
+		} else if rangeHeader != "" {
+			// The range is forwarded upstream while the whole artifact is
+			// stored in the background, so later resumes are served locally
+			h.warmArtifact(categoryID, resolvedVersion, buildStr, download, key)
+		}
+	}
+
+	// Request the download from upstream
+	resp, err := h.fetchDownload(ctx, http.MethodGet, download.UpstreamURL, rangeHeader)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, APIResponse{
 			Success: false,
@@ -341,7 +524,13 @@ func (h *Handler) GetDownload(c *gin.Context) {
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		c.Header("Content-Range", resp.Header.Get("Content-Range"))
+		c.Status(http.StatusRequestedRangeNotSatisfiable)
+		return
+	default:
 		c.JSON(http.StatusBadGateway, APIResponse{
 			Success: false,
 			Error:   fmt.Sprintf("upstream returned status %d", resp.StatusCode),
@@ -349,24 +538,85 @@ func (h *Handler) GetDownload(c *gin.Context) {
 		return
 	}
 
-	// Determine filename
-	filename := download.Name
-	if filename == "" {
-		filename = fmt.Sprintf("%s-%s-%d.jar", categoryID, resolvedVersion, build.Number)
-	}
-
 	// Set response headers
-	c.Header("Content-Type", "application/java-archive")
+	c.Header("Content-Type", artifacts.ContentType(filename))
 	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	setChecksumHeaders(c, download)
+	if cacheable || resp.Header.Get("Accept-Ranges") == "bytes" {
+		c.Header("Accept-Ranges", "bytes")
+	}
 
 	// Forward Content-Length if available
 	if resp.ContentLength > 0 {
 		c.Header("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
 	}
 
-	// Stream the response body directly to client (no disk storage)
+	if resp.StatusCode == http.StatusPartialContent {
+		// A partial download can be neither verified nor stored
+		c.Header("Content-Range", resp.Header.Get("Content-Range"))
+		c.Status(http.StatusPartialContent)
+		_, _ = io.Copy(c.Writer, resp.Body)
+		return
+	}
+
 	c.Status(http.StatusOK)
-	_, _ = io.Copy(c.Writer, resp.Body)
+	h.recordDownload(c, categoryID, downloadViaProxy)
+
+	dst := io.Writer(c.Writer)
+
+	// Compute the SHA-256 of downloads whose upstream does not report one,
+	// so it is served with the build from now on
+	var checksum *checksumWriter
+	if download.SHA256 == "" {
+		checksum = newChecksumWriter()
+		dst = io.MultiWriter(dst, checksum)
+	}
+
+	verifier, verifiable := artifacts.NewVerifier(download)
+	if !verifiable {
+		// No known hash: stream the response body directly to client
+		if _, err := io.Copy(dst, resp.Body); err == nil {
+			h.recordChecksum(c, categoryID, resolvedVersion, build, download, checksum)
+		}
+		return
+	}
+
+	// Store the artifact while streaming it to the client
+	var store *storeWriter
+	if cacheable {
+		if w, err := h.artifacts.Create(ctx, key); err != nil {
+			log.Printf("Warning: Failed to create artifact %s: %v", key, err)
+		} else {
+			store = &storeWriter{w: w}
+			dst = io.MultiWriter(dst, store)
+		}
+	}
+
+	if err := copyVerified(dst, resp.Body, verifier); err != nil {
+		if store != nil {
+			store.w.Abort()
+		}
+
+		var mismatch *artifacts.MismatchError
+		if errors.As(err, &mismatch) {
+			h.recordIncident(categoryID, resolvedVersion, buildStr, mismatch)
+			abortConnection(c)
+		}
+		return
+	}
+	h.recordChecksum(c, categoryID, resolvedVersion, build, download, checksum)
+
+	if store == nil {
+		return
+	}
+	if store.err != nil {
+		store.w.Abort()
+		log.Printf("Warning: Failed to store artifact %s: %v", key, store.err)
+		return
+	}
+	if err := store.w.Commit(); err != nil {
+		log.Printf("Warning: Failed to store artifact %s: %v", key, err)
+	}
 }
 
 // Search handles GET /search
@@ -427,8 +677,5 @@ func (h *Handler) Search(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, APIResponse{
-		Success: true,
-		Data:    results,
-	})
+	h.respond(c, results)
 }
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/service"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errInvalidBuild, http.StatusBadRequest},
+		{errInvalidInstaller, http.StatusBadRequest},
+		{fmt.Errorf("paper: %w", service.ErrNoInstallers), http.StatusBadRequest},
+		{fmt.Errorf("fetching: %w", upstream.ErrRateLimited), http.StatusTooManyRequests},
+		{fmt.Errorf("fetching: %w", upstream.ErrUpstreamUnavailable), http.StatusBadGateway},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("build 5 not found"), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,12 +1,22 @@
 package java
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
 )
@@ -15,7 +25,9 @@ import (
 type JavaConfig struct {
 	Servers []VersionRequirement `json:"servers"`
 	Proxies []VersionRequirement `json:"proxies"`
-	Default int                  `json:"default"`
+	// Categories override the servers/proxies mapping per category
+	Categories map[string][]VersionRequirement `json:"categories,omitempty"`
+	Default    int                             `json:"default"`
 }
 
 // VersionRequirement represents a minimum version and its Java requirement
@@ -24,40 +36,236 @@ type VersionRequirement struct {
 	Java       int    `json:"java"`
 }
 
+// Config holds Java mapping file configuration
+type Config struct {
+	Path         string
+	PollInterval time.Duration // How often the file is checked for changes (0 = SIGHUP only)
+}
+
+// DefaultConfig returns default Java mapping configuration from environment
+func DefaultConfig() Config {
+	path := os.Getenv("JAVA_CONFIG_PATH")
+	if path == "" {
+		path = "java.json"
+	}
+
+	pollInterval := 5 * time.Second
+	if intervalStr := os.Getenv("JAVA_CONFIG_POLL_INTERVAL"); intervalStr != "" {
+		if d, err := time.ParseDuration(intervalStr); err == nil && d >= 0 {
+			pollInterval = d
+		}
+	}
+
+	return Config{
+		Path:         path,
+		PollInterval: pollInterval,
+	}
+}
+
+// knownCategories are the categories a mapping can be overridden for
+var knownCategories = []models.Category{
+	models.CategoryVanilla,
+	models.CategoryPaper,
+	models.CategorySpigot,
+	models.CategoryPurpur,
+	models.CategoryFolia,
+	models.CategoryVelocity,
+	models.CategoryBungeeCord,
+	models.CategoryWaterfall,
+	models.CategoryFabric,
+	models.CategoryForge,
+	models.CategoryNeoForge,
+}
+
 var (
 	config     *JavaConfig
+	configMu   sync.RWMutex
 	configOnce sync.Once
-	configErr  error
 
 	// Weekly snapshot pattern: YYwWWx (e.g., 25w46a, 24w33a)
 	weeklySnapshotRegex = regexp.MustCompile(`^(\d{2})w(\d{2})[a-z]$`)
 )
 
-// loadConfig loads the Java configuration from file
-func loadConfig() (*JavaConfig, error) {
+// Load reads and validates the Java configuration file and makes it current
+func Load(path string) error {
+	_, err := reload(path)
+	return err
+}
+
+// reload loads the Java configuration file and returns the categories whose
+// mapping changed. The current configuration is kept if the file is invalid.
+func reload(path string) ([]models.Category, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading Java config: %w", err)
+	}
+
+	cfg := &JavaConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("parsing Java config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Java config %s: %w", path, err)
+	}
+
+	configMu.Lock()
+	previous := config
+	config = cfg
+	configMu.Unlock()
+
+	return changedCategories(previous, cfg), nil
+}
+
+// currentConfig returns the current configuration, loading it from
+// JAVA_CONFIG_PATH on first use if Load was never called
+func currentConfig() *JavaConfig {
 	configOnce.Do(func() {
-		path := os.Getenv("JAVA_CONFIG_PATH")
-		if path == "" {
-			path = "java.json"
+		configMu.RLock()
+		loaded := config != nil
+		configMu.RUnlock()
+
+		if !loaded {
+			if err := Load(DefaultConfig().Path); err != nil {
+				log.Printf("Warning: %v", err)
+			}
 		}
+	})
+
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// Validate checks that every mapping is ordered from the newest to the
+// oldest version without duplicates, and that overrides name known categories
+func (c *JavaConfig) Validate() error {
+	var errs []error
+	if c.Default <= 0 {
+		errs = append(errs, fmt.Errorf("default: Java version must be positive, got %d", c.Default))
+	}
+
+	errs = append(errs, validateRequirements("servers", c.Servers)...)
+	errs = append(errs, validateRequirements("proxies", c.Proxies)...)
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			configErr = err
-			return
+	categories := make([]string, 0, len(c.Categories))
+	for category := range c.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		if !slices.Contains(knownCategories, models.Category(category)) {
+			errs = append(errs, fmt.Errorf("categories: unknown category %q", category))
+			continue
 		}
+		errs = append(errs, validateRequirements("categories."+category, c.Categories[category])...)
+	}
 
-		config = &JavaConfig{}
-		configErr = json.Unmarshal(data, config)
-	})
+	return errors.Join(errs...)
+}
+
+// validateRequirements checks a single mapping
+func validateRequirements(name string, requirements []VersionRequirement) []error {
+	var errs []error
+	for i, req := range requirements {
+		if req.Java <= 0 {
+			errs = append(errs, fmt.Errorf("%s[%d]: Java version must be positive, got %d", name, i, req.Java))
+		}
+		if req.MinVersion != "0" && len(parseVersionParts(req.MinVersion)) == 0 {
+			errs = append(errs, fmt.Errorf("%s[%d]: invalid min_version %q", name, i, req.MinVersion))
+			continue
+		}
+
+		for j := 0; j < i; j++ {
+			prev := requirements[j].MinVersion
+			if prev == req.MinVersion || (req.MinVersion != "0" && compareVersions(prev, req.MinVersion) == 0) {
+				errs = append(errs, fmt.Errorf("%s[%d]: duplicate min_version %q (same as %s[%d])", name, i, req.MinVersion, name, j))
+				break
+			}
+			if j == i-1 && compareVersions(prev, req.MinVersion) < 0 {
+				errs = append(errs, fmt.Errorf("%s[%d]: min_version %q must be lower than the previous %q (newest first)", name, i, req.MinVersion, prev))
+			}
+		}
+	}
+	return errs
+}
+
+// requirements returns the mapping used for a category
+func (c *JavaConfig) requirements(category models.Category) []VersionRequirement {
+	if requirements, ok := c.Categories[string(category)]; ok {
+		return requirements
+	}
+	if isProxy(category) {
+		return c.Proxies
+	}
+	return c.Servers
+}
+
+// changedCategories returns the categories whose mapping differs between
+// two configurations. All categories changed if there was none before.
+func changedCategories(previous, current *JavaConfig) []models.Category {
+	var changed []models.Category
+	for _, category := range knownCategories {
+		if previous == nil || previous.Default != current.Default ||
+			!slices.Equal(previous.requirements(category), current.requirements(category)) {
+			changed = append(changed, category)
+		}
+	}
+	return changed
+}
 
-	return config, configErr
+// Watch reloads the Java configuration file on SIGHUP and whenever it
+// changes on disk, until ctx is done. onChange is called with the
+// categories whose mapping changed. An invalid file is logged and ignored.
+func Watch(ctx context.Context, cfg Config, onChange func([]models.Category)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	last, _ := os.Stat(cfg.Path)
+	go func() {
+		defer signal.Stop(hup)
+
+		var poll <-chan time.Time
+		if cfg.PollInterval > 0 {
+			ticker := time.NewTicker(cfg.PollInterval)
+			defer ticker.Stop()
+			poll = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Printf("Received SIGHUP, reloading Java config from %s", cfg.Path)
+			case <-poll:
+				info, err := os.Stat(cfg.Path)
+				if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+					continue
+				}
+				last = info
+			}
+
+			changed, err := reload(cfg.Path)
+			if err != nil {
+				log.Printf("Warning: Keeping previous Java config: %v", err)
+				continue
+			}
+			log.Printf("Reloaded Java config from %s (%d categories changed)", cfg.Path, len(changed))
+			if len(changed) > 0 && onChange != nil {
+				onChange(changed)
+			}
+		}
+	}()
 }
 
 // GetRequirement returns Java version requirement for a Minecraft version
+// It is a fallback for versions whose provider does not report one
 func GetRequirement(version string, category models.Category) int {
-	cfg, err := loadConfig()
-	if err != nil {
+	cfg := currentConfig()
+	if cfg == nil {
 		return 17 // Safe default
 	}
 
@@ -100,16 +308,8 @@ func GetRequirement(version string, category models.Category) int {
 		return 8
 	}
 
-	// Determine which requirements to use
-	var requirements []VersionRequirement
-	if isProxy(category) {
-		requirements = cfg.Proxies
-	} else {
-		requirements = cfg.Servers
-	}
-
 	// Find matching requirement
-	for _, req := range requirements {
+	for _, req := range cfg.requirements(category) {
 		if compareVersions(version, req.MinVersion) >= 0 {
 			return req.Java
 		}
@@ -121,7 +321,8 @@ func GetRequirement(version string, category models.Category) int {
 // isProxy returns true if the category is a proxy server
 func isProxy(category models.Category) bool {
 	return category == models.CategoryVelocity ||
-		category == models.CategoryBungeeCord
+		category == models.CategoryBungeeCord ||
+		category == models.CategoryWaterfall
 }
 
 // compareVersions compares two version strings
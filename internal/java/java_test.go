@@ -0,0 +1,181 @@
+package java
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+func validConfig() *JavaConfig {
+	return &JavaConfig{
+		Servers: []VersionRequirement{
+			{MinVersion: "1.20.5", Java: 21},
+			{MinVersion: "1.17", Java: 17},
+			{MinVersion: "0", Java: 8},
+		},
+		Proxies: []VersionRequirement{
+			{MinVersion: "3.3.0", Java: 17},
+			{MinVersion: "0", Java: 11},
+		},
+		Default: 21,
+	}
+}
+
+func TestJavaConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *JavaConfig)
+		wantErr []string // Substrings of the expected error, none if valid
+	}{
+		{
+			name:   "valid",
+			modify: func(c *JavaConfig) {},
+		},
+		{
+			name: "valid category override",
+			modify: func(c *JavaConfig) {
+				c.Categories = map[string][]VersionRequirement{"forge": {{MinVersion: "1.18", Java: 17}}}
+			},
+		},
+		{
+			name:    "non-positive default",
+			modify:  func(c *JavaConfig) { c.Default = 0 },
+			wantErr: []string{"default: Java version must be positive"},
+		},
+		{
+			name:    "non-positive Java version",
+			modify:  func(c *JavaConfig) { c.Servers[1].Java = -1 },
+			wantErr: []string{"servers[1]: Java version must be positive"},
+		},
+		{
+			name:    "invalid min_version",
+			modify:  func(c *JavaConfig) { c.Proxies[0].MinVersion = "latest" },
+			wantErr: []string{`proxies[0]: invalid min_version "latest"`},
+		},
+		{
+			name: "oldest first",
+			modify: func(c *JavaConfig) {
+				c.Servers[0], c.Servers[1] = c.Servers[1], c.Servers[0]
+			},
+			wantErr: []string{`servers[1]: min_version "1.20.5" must be lower than the previous "1.17"`},
+		},
+		{
+			name: "duplicate",
+			modify: func(c *JavaConfig) {
+				c.Servers = slices.Insert(c.Servers, 2, VersionRequirement{MinVersion: "1.17.0", Java: 16})
+			},
+			wantErr: []string{`servers[2]: duplicate min_version "1.17.0" (same as servers[1])`},
+		},
+		{
+			name: "unknown category",
+			modify: func(c *JavaConfig) {
+				c.Categories = map[string][]VersionRequirement{"spongeforge": {{MinVersion: "0", Java: 8}}}
+			},
+			wantErr: []string{`categories: unknown category "spongeforge"`},
+		},
+		{
+			name: "invalid category override",
+			modify: func(c *JavaConfig) {
+				c.Categories = map[string][]VersionRequirement{"fabric": {{MinVersion: "1.14", Java: 0}}}
+			},
+			wantErr: []string{"categories.fabric[0]: Java version must be positive"},
+		},
+		{
+			name: "reports every error",
+			modify: func(c *JavaConfig) {
+				c.Default = 0
+				c.Proxies[1].Java = 0
+			},
+			wantErr: []string{"default:", "proxies[1]:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChangedCategories(t *testing.T) {
+	proxies := []models.Category{models.CategoryVelocity, models.CategoryBungeeCord, models.CategoryWaterfall}
+
+	tests := []struct {
+		name     string
+		previous *JavaConfig
+		modify   func(c *JavaConfig)
+		want     []models.Category
+	}{
+		{
+			name:     "first load",
+			previous: nil,
+			modify:   func(c *JavaConfig) {},
+			want:     knownCategories,
+		},
+		{
+			name:     "unchanged",
+			previous: validConfig(),
+			modify:   func(c *JavaConfig) {},
+			want:     nil,
+		},
+		{
+			name:     "default changed",
+			previous: validConfig(),
+			modify:   func(c *JavaConfig) { c.Default = 17 },
+			want:     knownCategories,
+		},
+		{
+			name:     "proxies changed",
+			previous: validConfig(),
+			modify:   func(c *JavaConfig) { c.Proxies[0].Java = 21 },
+			want:     proxies,
+		},
+		{
+			name:     "category override added",
+			previous: validConfig(),
+			modify: func(c *JavaConfig) {
+				c.Categories = map[string][]VersionRequirement{"forge": {{MinVersion: "0", Java: 8}}}
+			},
+			want: []models.Category{models.CategoryForge},
+		},
+		{
+			name:     "override equal to the mapping it replaces",
+			previous: validConfig(),
+			modify: func(c *JavaConfig) {
+				c.Categories = map[string][]VersionRequirement{"velocity": slices.Clone(c.Proxies)}
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := validConfig()
+			tt.modify(current)
+
+			got := changedCategories(tt.previous, current)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("changedCategories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
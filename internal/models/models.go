@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Category represents a category of Minecraft server software
 type Category string
@@ -13,6 +18,10 @@ const (
 	CategoryFolia      Category = "folia"
 	CategoryVelocity   Category = "velocity"
 	CategoryBungeeCord Category = "bungeecord"
+	CategoryWaterfall  Category = "waterfall"
+	CategoryFabric     Category = "fabric"
+	CategoryForge      Category = "forge"
+	CategoryNeoForge   Category = "neoforge"
 )
 
 // VersionType represents the type of version (release, snapshot, etc.)
@@ -33,11 +42,31 @@ type Version struct {
 	Stable      bool        `json:"stable"`
 	Supported   bool        `json:"supported"`
 	Java        int         `json:"java,omitempty"`
+
+	// JavaRequirement is set if the upstream reports it (Java is then its minimum)
+	JavaRequirement *JavaRequirement `json:"java_requirement,omitempty"`
+}
+
+// JavaRequirement describes the Java runtime required by a version or build
+type JavaRequirement struct {
+	Minimum int      `json:"minimum"`
+	Flags   []string `json:"flags,omitempty"` // Recommended JVM flags
+}
+
+// VersionState is data derived from the build list of a version (internal use).
+// Providers keep it so they only refetch versions whose builds changed.
+type VersionState struct {
+	Fingerprint string    `json:"fingerprint"` // Hash of the full build list
+	ReleaseTime time.Time `json:"release_time"`
+	HasStable   bool      `json:"has_stable"`
+	Java        int       `json:"java,omitempty"` // Required Java version, if reported upstream
+	CheckedAt   time.Time `json:"checked_at"`
 }
 
 // Build represents a specific build of server software for a version
 type Build struct {
 	Number    int        `json:"number"`
+	ID        BuildID    `json:"id,omitempty"` // String build identifier (e.g. Forge "47.2.0")
 	Version   string     `json:"version"`
 	Channel   string     `json:"channel,omitempty"`
 	Stable    bool       `json:"stable"`
@@ -45,15 +74,160 @@ type Build struct {
 	Downloads []Download `json:"downloads,omitempty"`
 	Changes   []Change   `json:"changes,omitempty"`
 	Java      int        `json:"java,omitempty"`
+
+	// JavaRequirement is set if the upstream reports it (Java is then its minimum)
+	JavaRequirement *JavaRequirement `json:"java_requirement,omitempty"`
+
+	// Mod loader builds (e.g. Fabric) are a loader + installer pair
+	Loader    string `json:"loader,omitempty"`
+	Installer string `json:"installer,omitempty"`
+
+	// FirstSeen is set on builds served from the catalog database
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+}
+
+// Download returns the download of the given kind, or the primary download
+// if kind is empty
+func (b *Build) Download(kind string) (*Download, bool) {
+	for i := range b.Downloads {
+		if kind == "" || b.Downloads[i].Kind == kind {
+			return &b.Downloads[i], true
+		}
+	}
+	return nil, false
+}
+
+// Matches reports whether the build is addressed by the given identifier.
+// Numeric identifiers match the build number, semantic identifiers match the
+// build ID and commit hashes match the newest commit included in the build.
+// An all-digit identifier can be either a build number or a commit hash.
+// Builds that pair a loader with an installer (Fabric) are only addressed by
+// their ID or loader version, as their numbers shift when loaders are released.
+func (b *Build) Matches(id BuildID) bool {
+	if b.ID != "" && b.ID == id {
+		return true
+	}
+	if b.Loader != "" {
+		return b.Loader == string(id)
+	}
+	if n, ok := id.Number(); ok && b.Number == n {
+		return true
+	}
+	if id.IsCommitHash() && len(b.Changes) > 0 {
+		return strings.HasPrefix(strings.ToLower(b.Changes[0].Commit), strings.ToLower(string(id)))
+	}
+	return false
+}
+
+// FindBuild returns the build addressed by id, preferring an exact build ID
+// over a build number or commit hash match
+func FindBuild(builds []Build, id BuildID) (*Build, bool) {
+	for i := range builds {
+		if builds[i].ID != "" && builds[i].ID == id {
+			return &builds[i], true
+		}
+	}
+	for i := range builds {
+		if builds[i].Matches(id) {
+			return &builds[i], true
+		}
+	}
+	return nil, false
+}
+
+// BuildID identifies a build of a version. It can be a sequential build
+// number (Paper "123"), a semantic build string (Forge "47.2.0", Fabric
+// loader "0.16.9") or a commit hash ("a1b2c3d").
+type BuildID string
+
+// NewBuildNumber returns a BuildID for a sequential build number
+func NewBuildNumber(n int) BuildID {
+	return BuildID(strconv.Itoa(n))
+}
+
+// ParseBuildID validates a build identifier taken from user input
+func ParseBuildID(s string) (BuildID, error) {
+	if s == "" || len(s) > 128 || s[0] == '+' || s[0] == '-' {
+		return "", fmt.Errorf("invalid build identifier")
+	}
+
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c == '.' || c == '-' || c == '_' || c == '+') {
+			return "", fmt.Errorf("invalid build identifier")
+		}
+	}
+
+	return BuildID(s), nil
 }
 
+// Number returns the sequential build number if the identifier is numeric
+func (id BuildID) Number() (int, bool) {
+	if id == "" {
+		return 0, false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// IsCommitHash reports whether the identifier looks like an (abbreviated) git commit hash
+func (id BuildID) IsCommitHash() bool {
+	if len(id) < 7 || len(id) > 40 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func (id BuildID) String() string {
+	return string(id)
+}
+
+// Download kinds are "<role>:<variant>", following the download keys of the
+// PaperMC Fill API (e.g. "server:default", "server:mojmap")
+const (
+	DownloadKindServer         = "server:default"
+	DownloadKindInstaller      = "installer:default"
+	DownloadKindClient         = "client:default"
+	DownloadKindServerMappings = "server:mappings"
+	DownloadKindClientMappings = "client:mappings"
+)
+
 // Download represents a downloadable file (internal use - includes upstream URL)
+// The primary download of a build comes first.
 type Download struct {
-	Name        string `json:"name"`
-	SHA256      string `json:"sha256,omitempty"`
-	SHA1        string `json:"sha1,omitempty"`
-	Size        int64  `json:"size,omitempty"`
-	UpstreamURL string `json:"-"` // Hidden from JSON, internal use only
+	Kind   string `json:"kind,omitempty"`
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	MD5    string `json:"md5,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	// SHA256Computed reports that SHA256 and Size were computed from the first
+	// download rather than reported upstream. They are informational only and
+	// never used to verify or store downloads.
+	SHA256Computed bool   `json:"sha256_computed,omitempty"`
+	UpstreamURL    string `json:"-"` // Hidden from JSON, internal use only
+}
+
+// InstallerVersion is a release of an installer (launcher) that builds can
+// be paired with, e.g. the Fabric server launcher
+type InstallerVersion struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Latest  bool   `json:"latest"` // Paired with builds unless another installer is selected
 }
 
 // Change represents a change in a build (commit, changelog entry)
@@ -68,6 +242,7 @@ type CategoryInfo struct {
 	ID          Category        `json:"id"`
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
+	Archived    bool            `json:"archived"` // Project is end-of-life and no longer maintained upstream
 	Filters     CategoryFilters `json:"filters"`
 }
 
@@ -0,0 +1,82 @@
+package models
+
+import "testing"
+
+func TestFindBuild(t *testing.T) {
+	builds := []Build{
+		{Number: 3, ID: "0.16.10+1.0.1", Loader: "0.16.10", Installer: "1.0.1"},
+		{Number: 2, ID: "0.16.9+1.0.1", Loader: "0.16.9", Installer: "1.0.1"},
+		{Number: 1, ID: "0.16.8+1.0.1", Loader: "0.16.8", Installer: "1.0.1"},
+	}
+
+	tests := []struct {
+		id     BuildID
+		want   BuildID
+		wantOk bool
+	}{
+		{"0.16.9+1.0.1", "0.16.9+1.0.1", true},
+		{"0.16.9", "0.16.9+1.0.1", true},
+		{"2", "", false}, // Loader builds are not addressed by number
+		{"0.16.7", "", false},
+	}
+
+	for _, tt := range tests {
+		b, ok := FindBuild(builds, tt.id)
+		if ok != tt.wantOk {
+			t.Errorf("FindBuild(%q) ok = %v, want %v", tt.id, ok, tt.wantOk)
+			continue
+		}
+		if ok && b.ID != tt.want {
+			t.Errorf("FindBuild(%q) = %q, want %q", tt.id, b.ID, tt.want)
+		}
+	}
+
+	numbered := []Build{{Number: 124}, {Number: 123}}
+	if b, ok := FindBuild(numbered, "123"); !ok || b.Number != 123 {
+		t.Errorf("FindBuild(123) = %v, %v, want build 123", b, ok)
+	}
+}
+
+func TestParseBuildID(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"123", false},
+		{"47.2.0", false},
+		{"0.16.9+1.0.1", false},
+		{"1.20.1-47.2.0", false},
+		{"", true},
+		{"+5", true},
+		{"-5", true},
+		{"../etc", true},
+		{"a b", true},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseBuildID(tt.input); (err != nil) != tt.wantErr {
+			t.Errorf("ParseBuildID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBuildMatches(t *testing.T) {
+	b := Build{Number: 1234567, ID: "47.2.0", Changes: []Change{{Commit: "a1b2c3d4e5f6"}}}
+
+	tests := []struct {
+		id   BuildID
+		want bool
+	}{
+		{"47.2.0", true},
+		{"1234567", true}, // Build number
+		{"A1B2C3D", true}, // Commit hash prefix, any case
+		{"47.2", false},
+		{"b1b2c3d", false},
+	}
+
+	for _, tt := range tests {
+		if got := b.Matches(tt.id); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -2,17 +2,13 @@ package providers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
-)
-
-const (
-	bungeecordJenkinsURL = "https://ci.md-5.net/job/BungeeCord"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 )
 
 // JenkinsJobInfo represents Jenkins job information
@@ -43,17 +39,15 @@ type JenkinsArtifact struct {
 
 // BungeeCordProvider implements Provider for BungeeCord
 type BungeeCordProvider struct {
-	client *http.Client
+	client *upstream.Client
 	config ProviderConfig
 }
 
 // NewBungeeCordProvider creates a new BungeeCord provider
 func NewBungeeCordProvider(config ProviderConfig) *BungeeCordProvider {
 	return &BungeeCordProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config: config,
+		client: config.upstreamClient(),
+		config: config.forProvider("bungeecord"),
 	}
 }
 
@@ -76,34 +70,9 @@ func (p *BungeeCordProvider) GetFilters() models.CategoryFilters {
 	}
 }
 
-func (p *BungeeCordProvider) doRequest(ctx context.Context, url string, target interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("User-Agent", p.config.UserAgent)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("making request: %w", err)
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("not found")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("decoding response: %w", err)
-	}
-
-	return nil
+// ImmutableDownloads reports that Jenkins build artifacts never change
+func (p *BungeeCordProvider) ImmutableDownloads() bool {
+	return true
 }
 
 // BungeeCord doesn't have traditional "versions" like MC - it's continuously updated
@@ -123,10 +92,10 @@ func (p *BungeeCordProvider) GetVersions(_ context.Context) ([]models.Version, e
 }
 
 func (p *BungeeCordProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
-	url := fmt.Sprintf("%s/api/json?tree=builds[number,url]", bungeecordJenkinsURL)
+	url := fmt.Sprintf("%s/api/json?tree=builds[number,url]", p.config.URLs.BungeeCord)
 
 	var jobInfo JenkinsJobInfo
-	if err := p.doRequest(ctx, url, &jobInfo); err != nil {
+	if err := p.client.GetJSON(ctx, url, &jobInfo); err != nil {
 		return nil, err
 	}
 
@@ -136,45 +105,29 @@ func (p *BungeeCordProvider) GetBuilds(ctx context.Context, version string) ([]m
 		maxBuilds = len(jobInfo.Builds)
 	}
 
-	builds := make([]models.Build, 0, maxBuilds)
-	for i := 0; i < maxBuilds; i++ {
+	// Get build details in parallel; builds without a jar are skipped
+	results := make([]*models.Build, maxBuilds)
+	err := p.client.ForEach(ctx, maxBuilds, func(ctx context.Context, i int) {
 		buildRef := jobInfo.Builds[i]
 
 		// Get build details
-		buildURL := fmt.Sprintf("%s/%d/api/json", bungeecordJenkinsURL, buildRef.Number)
+		buildURL := fmt.Sprintf("%s/%d/api/json", p.config.URLs.BungeeCord, buildRef.Number)
 		var buildInfo JenkinsBuildInfo
-		if err := p.doRequest(ctx, buildURL, &buildInfo); err != nil {
-			continue
+		if err := p.client.GetJSON(ctx, buildURL, &buildInfo); err != nil {
+			return
 		}
 
-		// Find BungeeCord.jar artifact
-		var jarArtifact *JenkinsArtifact
-		for _, a := range buildInfo.Artifacts {
-			if a.FileName == "BungeeCord.jar" {
-				artifact := a
-				jarArtifact = &artifact
-				break
-			}
-		}
+		results[i] = p.newBuild(version, buildRef.Number, buildInfo)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if jarArtifact == nil {
-			continue
+	builds := make([]models.Build, 0, maxBuilds)
+	for _, b := range results {
+		if b != nil {
+			builds = append(builds, *b)
 		}
-
-		downloadURL := fmt.Sprintf("%s/%d/artifact/%s", bungeecordJenkinsURL, buildRef.Number, jarArtifact.RelativePath)
-
-		builds = append(builds, models.Build{
-			Number:    buildRef.Number,
-			Version:   version,
-			Stable:    buildInfo.Result == "SUCCESS",
-			CreatedAt: time.UnixMilli(buildInfo.Timestamp),
-			Downloads: []models.Download{
-				{
-					Name:        fmt.Sprintf("BungeeCord-%d.jar", buildRef.Number),
-					UpstreamURL: downloadURL,
-				},
-			},
-		})
 	}
 
 	// Sort by build number descending (newest first)
@@ -185,46 +138,78 @@ func (p *BungeeCordProvider) GetBuilds(ctx context.Context, version string) ([]m
 	return builds, nil
 }
 
-func (p *BungeeCordProvider) GetBuild(ctx context.Context, version string, build int) (*models.Build, error) {
-	buildURL := fmt.Sprintf("%s/%d/api/json", bungeecordJenkinsURL, build)
+func (p *BungeeCordProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	buildNum, ok := build.Number()
+	if !ok {
+		return nil, fmt.Errorf("build %s not found for version %s", build, version)
+	}
+
+	buildURL := fmt.Sprintf("%s/%d/api/json", p.config.URLs.BungeeCord, buildNum)
 
 	var buildInfo JenkinsBuildInfo
-	if err := p.doRequest(ctx, buildURL, &buildInfo); err != nil {
+	if err := p.client.GetJSON(ctx, buildURL, &buildInfo); err != nil {
 		return nil, err
 	}
 
-	// Find BungeeCord.jar artifact
-	var jarArtifact *JenkinsArtifact
+	b := p.newBuild(version, buildNum, buildInfo)
+	if b == nil {
+		return nil, fmt.Errorf("no BungeeCord.jar artifact found for build %d", buildNum)
+	}
+
+	return b, nil
+}
+
+// newBuild converts a Jenkins build to a models.Build with BungeeCord.jar as
+// the primary download, followed by the modules and other jar artifacts.
+// Returns nil if the build has no BungeeCord.jar.
+func (p *BungeeCordProvider) newBuild(version string, buildNum int, buildInfo JenkinsBuildInfo) *models.Build {
+	var primary *models.Download
+	var extras []models.Download
+
 	for _, a := range buildInfo.Artifacts {
-		if a.FileName == "BungeeCord.jar" {
-			artifact := a
-			jarArtifact = &artifact
-			break
+		if !strings.HasSuffix(a.FileName, ".jar") {
+			continue
+		}
+
+		download := models.Download{
+			UpstreamURL: fmt.Sprintf("%s/%d/artifact/%s", p.config.URLs.BungeeCord, buildNum, a.RelativePath),
+		}
+
+		name := strings.TrimSuffix(a.FileName, ".jar")
+		switch {
+		case a.FileName == "BungeeCord.jar":
+			download.Kind = models.DownloadKindServer
+			download.Name = fmt.Sprintf("BungeeCord-%d.jar", buildNum)
+			primary = &download
+			continue
+		case strings.HasPrefix(a.RelativePath, "module/"):
+			download.Kind = "module:" + name
+		default:
+			download.Kind = "artifact:" + name
 		}
+		download.Name = fmt.Sprintf("%s-%d.jar", name, buildNum)
+		extras = append(extras, download)
 	}
 
-	if jarArtifact == nil {
-		return nil, fmt.Errorf("no BungeeCord.jar artifact found for build %d", build)
+	if primary == nil {
+		return nil
 	}
 
-	downloadURL := fmt.Sprintf("%s/%d/artifact/%s", bungeecordJenkinsURL, build, jarArtifact.RelativePath)
+	sort.Slice(extras, func(i, j int) bool {
+		return extras[i].Kind < extras[j].Kind
+	})
 
 	return &models.Build{
-		Number:    build,
+		Number:    buildNum,
 		Version:   version,
 		Stable:    buildInfo.Result == "SUCCESS",
 		CreatedAt: time.UnixMilli(buildInfo.Timestamp),
-		Downloads: []models.Download{
-			{
-				Name:        fmt.Sprintf("BungeeCord-%d.jar", build),
-				UpstreamURL: downloadURL,
-			},
-		},
-	}, nil
+		Downloads: append([]models.Download{*primary}, extras...),
+	}
 }
 
 func (p *BungeeCordProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
-	url := fmt.Sprintf("%s/api/json?tree=lastSuccessfulBuild[number]", bungeecordJenkinsURL)
+	url := fmt.Sprintf("%s/api/json?tree=lastSuccessfulBuild[number]", p.config.URLs.BungeeCord)
 
 	var result struct {
 		LastSuccessfulBuild struct {
@@ -232,14 +217,14 @@ func (p *BungeeCordProvider) GetLatestBuild(ctx context.Context, version string)
 		} `json:"lastSuccessfulBuild"`
 	}
 
-	if err := p.doRequest(ctx, url, &result); err != nil {
+	if err := p.client.GetJSON(ctx, url, &result); err != nil {
 		return nil, err
 	}
 
-	return p.GetBuild(ctx, version, result.LastSuccessfulBuild.Number)
+	return p.GetBuild(ctx, version, models.NewBuildNumber(result.LastSuccessfulBuild.Number))
 }
 
-func (p *BungeeCordProvider) GetDownloadURL(ctx context.Context, version string, build int) (string, error) {
+func (p *BungeeCordProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
 	b, err := p.GetBuild(ctx, version, build)
 	if err != nil {
 		return "", err
@@ -0,0 +1,41 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Downloader fetches jars from upstream with the same User-Agent and URL
+// rewrites as the providers. Jars can take long to stream, so only the wait
+// for the response headers is bounded.
+type Downloader struct {
+	client *http.Client
+	config ProviderConfig
+}
+
+// NewDownloader creates a downloader for the given provider configuration
+func NewDownloader(config ProviderConfig) *Downloader {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Duration(config.Timeout) * time.Second
+
+	return &Downloader{
+		client: &http.Client{Transport: transport},
+		config: config,
+	}
+}
+
+// Fetch requests a download, forwarding a Range header if set
+func (d *Downloader) Fetch(ctx context.Context, method, url, rangeHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.config.rewriteURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating download request: %w", err)
+	}
+	req.Header.Set("User-Agent", d.config.UserAgent)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	return d.client.Do(req)
+}
@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDownloaderFetch(t *testing.T) {
+	var gotPath, gotUA, gotRange string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotUA, gotRange = r.URL.Path, r.Header.Get("User-Agent"), r.Header.Get("Range")
+	}))
+	defer mirror.Close()
+
+	cfg := DefaultConfig()
+	cfg.URLRewrites = map[string]string{"https://piston-data.mojang.com": mirror.URL + "/piston-data"}
+	d := NewDownloader(cfg)
+
+	resp, err := d.Fetch(context.Background(), http.MethodGet, "https://piston-data.mojang.com/v1/objects/abc/server.jar", "bytes=10-")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if gotPath != "/piston-data/v1/objects/abc/server.jar" {
+		t.Errorf("fetched %q, want the rewritten mirror path", gotPath)
+	}
+	if gotUA != cfg.UserAgent {
+		t.Errorf("User-Agent = %q, want %q", gotUA, cfg.UserAgent)
+	}
+	if gotRange != "bytes=10-" {
+		t.Errorf("Range = %q, want it forwarded", gotRange)
+	}
+}
+
+func TestDownloaderResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDownloader(DefaultConfig())
+	d.client.Transport.(*http.Transport).ResponseHeaderTimeout = 20 * time.Millisecond
+
+	if _, err := d.Fetch(context.Background(), http.MethodGet, srv.URL, ""); err == nil {
+		t.Fatal("Fetch() error = nil, want a timeout")
+	}
+}
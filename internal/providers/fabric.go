@@ -0,0 +1,296 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+// FabricGameVersion represents a game version from the Fabric meta API
+type FabricGameVersion struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// FabricLoaderVersion represents a loader version from the Fabric meta API
+type FabricLoaderVersion struct {
+	Separator string `json:"separator"`
+	Build     int    `json:"build"`
+	Maven     string `json:"maven"`
+	Version   string `json:"version"`
+	Stable    bool   `json:"stable"`
+}
+
+// FabricInstallerVersion represents an installer version from the Fabric meta API
+type FabricInstallerVersion struct {
+	URL     string `json:"url"`
+	Maven   string `json:"maven"`
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// FabricLoaderEntry represents a loader entry for a specific game version
+type FabricLoaderEntry struct {
+	Loader FabricLoaderVersion `json:"loader"`
+}
+
+// FabricProvider implements Provider for the Fabric server launcher
+type FabricProvider struct {
+	client *upstream.Client
+	config ProviderConfig
+}
+
+// NewFabricProvider creates a new Fabric provider
+func NewFabricProvider(config ProviderConfig) *FabricProvider {
+	return &FabricProvider{
+		client: config.upstreamClient(),
+		config: config.forProvider("fabric"),
+	}
+}
+
+func (p *FabricProvider) GetID() string {
+	return "fabric"
+}
+
+func (p *FabricProvider) GetName() string {
+	return "Fabric"
+}
+
+func (p *FabricProvider) GetCategory() models.Category {
+	return models.CategoryFabric
+}
+
+func (p *FabricProvider) GetFilters() models.CategoryFilters {
+	return models.CategoryFilters{
+		Types:  []models.VersionType{models.VersionTypeRelease, models.VersionTypeSnapshot},
+		Stable: true,
+		Java:   true,
+	}
+}
+
+func (p *FabricProvider) GetVersions(ctx context.Context) ([]models.Version, error) {
+	var gameVersions []FabricGameVersion
+	if err := p.client.GetJSON(ctx, p.config.URLs.FabricMeta+"/versions/game", &gameVersions); err != nil {
+		return nil, err
+	}
+
+	// Fabric meta already returns newest first
+	versions := make([]models.Version, 0, len(gameVersions))
+	for _, v := range gameVersions {
+		vType := models.VersionTypeRelease
+		if !v.Stable {
+			vType = models.VersionTypeSnapshot
+		}
+
+		versions = append(versions, models.Version{
+			ID:     v.Version,
+			Type:   vType,
+			Stable: v.Stable,
+		})
+	}
+
+	return versions, nil
+}
+
+// fetchLoaders returns all loader versions (newest first)
+func (p *FabricProvider) fetchLoaders(ctx context.Context) ([]FabricLoaderVersion, error) {
+	var loaders []FabricLoaderVersion
+	if err := p.client.GetJSON(ctx, p.config.URLs.FabricMeta+"/versions/loader", &loaders); err != nil {
+		return nil, err
+	}
+	return loaders, nil
+}
+
+// fetchInstallers returns all installer versions (newest first)
+func (p *FabricProvider) fetchInstallers(ctx context.Context) ([]FabricInstallerVersion, error) {
+	var installers []FabricInstallerVersion
+	if err := p.client.GetJSON(ctx, p.config.URLs.FabricMeta+"/versions/installer", &installers); err != nil {
+		return nil, err
+	}
+
+	if len(installers) == 0 {
+		return nil, fmt.Errorf("no installer versions available")
+	}
+	return installers, nil
+}
+
+// latestInstaller returns the latest stable installer version, falling
+// back to the newest one if none is marked stable
+func latestInstaller(installers []FabricInstallerVersion) FabricInstallerVersion {
+	for _, i := range installers {
+		if i.Stable {
+			return i
+		}
+	}
+	return installers[0]
+}
+
+// GetInstallers returns the installer versions builds can be paired with
+func (p *FabricProvider) GetInstallers(ctx context.Context) ([]models.InstallerVersion, error) {
+	installers, err := p.fetchInstallers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := latestInstaller(installers)
+	versions := make([]models.InstallerVersion, 0, len(installers))
+	for _, i := range installers {
+		versions = append(versions, models.InstallerVersion{
+			Version: i.Version,
+			Stable:  i.Stable,
+			Latest:  i.Version == latest.Version,
+		})
+	}
+	return versions, nil
+}
+
+// fabricBuildID returns the ID of a loader + installer pair, e.g. "0.16.9+1.0.1"
+func fabricBuildID(loader, installer string) models.BuildID {
+	return models.BuildID(loader + "+" + installer)
+}
+
+// loaderBuildNumbers maps loader versions to sequential build numbers, the
+// oldest loader being build 1. The numbers only order builds in listings:
+// loaders can be added or withdrawn upstream, which shifts them, so builds
+// are addressed by their loader version (e.g. "0.16.9") instead.
+func loaderBuildNumbers(loaders []FabricLoaderVersion) map[string]int {
+	numbers := make(map[string]int, len(loaders))
+	for i, l := range loaders {
+		numbers[l.Version] = len(loaders) - i
+	}
+	return numbers
+}
+
+// newFabricBuild builds a models.Build for a loader + installer pair.
+// Its ID names both, so the pairing stays explicit.
+func (p *FabricProvider) newFabricBuild(version string, number int, loader FabricLoaderVersion, installer FabricInstallerVersion) models.Build {
+	downloadURL := fmt.Sprintf("%s/versions/loader/%s/%s/%s/server/jar", p.config.URLs.FabricMeta, version, loader.Version, installer.Version)
+
+	channel := "BETA"
+	if loader.Stable {
+		channel = "STABLE"
+	}
+
+	return models.Build{
+		Number:    number,
+		ID:        fabricBuildID(loader.Version, installer.Version),
+		Version:   version,
+		Channel:   channel,
+		Stable:    loader.Stable,
+		Loader:    loader.Version,
+		Installer: installer.Version,
+		Downloads: []models.Download{
+			{
+				Kind:        models.DownloadKindServer,
+				Name:        fmt.Sprintf("fabric-server-mc.%s-loader.%s-launcher.%s.jar", version, loader.Version, installer.Version),
+				UpstreamURL: downloadURL,
+			},
+		},
+	}
+}
+
+func (p *FabricProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
+	installers, err := p.fetchInstallers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.buildsWithInstaller(ctx, version, latestInstaller(installers))
+}
+
+// buildsWithInstaller returns the loader builds of a version paired with the given installer
+func (p *FabricProvider) buildsWithInstaller(ctx context.Context, version string, installer FabricInstallerVersion) ([]models.Build, error) {
+	var entries []FabricLoaderEntry
+	if err := p.client.GetJSON(ctx, fmt.Sprintf("%s/versions/loader/%s", p.config.URLs.FabricMeta, version), &entries); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no loader versions available for version %s", version)
+	}
+
+	loaders, err := p.fetchLoaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	numbers := loaderBuildNumbers(loaders)
+
+	builds := make([]models.Build, 0, len(entries))
+	for _, e := range entries {
+		number, ok := numbers[e.Loader.Version]
+		if !ok {
+			continue
+		}
+		builds = append(builds, p.newFabricBuild(version, number, e.Loader, installer))
+	}
+
+	return builds, nil
+}
+
+// GetBuild returns a build by loader version ("0.16.9", paired with the
+// latest installer) or loader + installer pair ("0.16.9+1.0.1")
+func (p *FabricProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	installers, err := p.fetchInstallers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	installer := latestInstaller(installers)
+	loader, installerVersion, paired := strings.Cut(build.String(), "+")
+	if paired {
+		i := slices.IndexFunc(installers, func(i FabricInstallerVersion) bool {
+			return i.Version == installerVersion
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("installer %s not found", installerVersion)
+		}
+		installer = installers[i]
+	}
+
+	builds, err := p.buildsWithInstaller(ctx, version, installer)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range builds {
+		if builds[i].Loader == loader {
+			return &builds[i], nil
+		}
+	}
+	return findBuild(builds, version, build)
+}
+
+func (p *FabricProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
+	builds, err := p.GetBuilds(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("no builds found for version %s", version)
+	}
+
+	for i := range builds {
+		if builds[i].Stable {
+			return &builds[i], nil
+		}
+	}
+
+	return &builds[0], nil
+}
+
+func (p *FabricProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
+	b, err := p.GetBuild(ctx, version, build)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b.Downloads) == 0 {
+		return "", fmt.Errorf("no download available")
+	}
+
+	return b.Downloads[0].UpstreamURL, nil
+}
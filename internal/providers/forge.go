@@ -0,0 +1,248 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+// ForgePromotions represents the Forge promotions_slim.json response
+type ForgePromotions struct {
+	Homepage string            `json:"homepage"`
+	Promos   map[string]string `json:"promos"` // e.g. "1.20.1-recommended": "47.2.0"
+}
+
+// ForgeProvider implements Provider for MinecraftForge installers
+type ForgeProvider struct {
+	client *upstream.Client
+	config ProviderConfig
+}
+
+// NewForgeProvider creates a new Forge provider
+func NewForgeProvider(config ProviderConfig) *ForgeProvider {
+	return &ForgeProvider{
+		client: config.upstreamClient(),
+		config: config.forProvider("forge"),
+	}
+}
+
+func (p *ForgeProvider) GetID() string {
+	return "forge"
+}
+
+func (p *ForgeProvider) GetName() string {
+	return "Forge"
+}
+
+func (p *ForgeProvider) GetCategory() models.Category {
+	return models.CategoryForge
+}
+
+func (p *ForgeProvider) GetFilters() models.CategoryFilters {
+	return models.CategoryFilters{
+		Types:    []models.VersionType{models.VersionTypeRelease, models.VersionTypeSnapshot},
+		Channels: []string{"LATEST", "RECOMMENDED"},
+		Stable:   true,
+		Java:     true,
+	}
+}
+
+// ImmutableDownloads reports that Maven release artifacts never change
+func (p *ForgeProvider) ImmutableDownloads() bool {
+	return true
+}
+
+// forgeArtifact is a single Forge Maven version split into its parts
+type forgeArtifact struct {
+	maven        string // Full Maven version, e.g. "1.7.10-10.13.4.1614-1.7.10"
+	mcVersion    string // e.g. "1.7.10"
+	forgeVersion string // e.g. "10.13.4.1614"
+}
+
+// parseForgeArtifact splits Forge's "mcversion-forgeversion[-branch]" scheme
+func parseForgeArtifact(maven string) (forgeArtifact, bool) {
+	parts := strings.SplitN(maven, "-", 3)
+	if len(parts) < 2 {
+		return forgeArtifact{}, false
+	}
+
+	return forgeArtifact{
+		maven:        maven,
+		mcVersion:    parts[0],
+		forgeVersion: parts[1],
+	}, true
+}
+
+// fetchArtifacts returns all Forge artifacts from Maven (oldest first)
+func (p *ForgeProvider) fetchArtifacts(ctx context.Context) ([]forgeArtifact, error) {
+	metadata, err := fetchMavenMetadata(ctx, p.client, p.config.URLs.ForgeMaven+"/maven-metadata.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]forgeArtifact, 0, len(metadata.Versioning.Versions))
+	for _, v := range metadata.Versioning.Versions {
+		if a, ok := parseForgeArtifact(v); ok {
+			artifacts = append(artifacts, a)
+		}
+	}
+
+	return artifacts, nil
+}
+
+func (p *ForgeProvider) fetchPromotions(ctx context.Context) (*ForgePromotions, error) {
+	var promotions ForgePromotions
+	if err := p.client.GetJSON(ctx, p.config.URLs.ForgePromotions, &promotions); err != nil {
+		return nil, err
+	}
+	return &promotions, nil
+}
+
+func (p *ForgeProvider) GetVersions(ctx context.Context) ([]models.Version, error) {
+	artifacts, err := p.fetchArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := p.fetchPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	versions := make([]models.Version, 0)
+	for _, a := range artifacts {
+		if seen[a.mcVersion] {
+			continue
+		}
+		seen[a.mcVersion] = true
+
+		vType := models.VersionTypeRelease
+		if strings.Contains(a.mcVersion, "_pre") || strings.Contains(a.mcVersion, "-pre") {
+			vType = models.VersionTypeSnapshot
+		}
+
+		_, hasRecommended := promotions.Promos[a.mcVersion+"-recommended"]
+
+		versions = append(versions, models.Version{
+			ID:     a.mcVersion,
+			Type:   vType,
+			Stable: vType == models.VersionTypeRelease && hasRecommended,
+		})
+	}
+
+	// Sort by semantic version (newest first)
+	p.SortVersions(versions)
+
+	return versions, nil
+}
+
+// SortVersions sorts versions by semantic version, newest first
+func (p *ForgeProvider) SortVersions(versions []models.Version) {
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].ID, versions[j].ID) > 0
+	})
+}
+
+func (p *ForgeProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
+	artifacts, err := p.fetchArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := p.fetchPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recommended := promotions.Promos[version+"-recommended"]
+	latest := promotions.Promos[version+"-latest"]
+
+	builds := make([]models.Build, 0)
+	for _, a := range artifacts {
+		if a.mcVersion != version {
+			continue
+		}
+
+		// Forge only promotes a handful of builds per version
+		var channel string
+		switch a.forgeVersion {
+		case recommended:
+			channel = "RECOMMENDED"
+		case latest:
+			channel = "LATEST"
+		}
+
+		downloadURL := fmt.Sprintf("%s/%s/forge-%s-installer.jar", p.config.URLs.ForgeMaven, a.maven, a.maven)
+
+		builds = append(builds, models.Build{
+			Number:  len(builds) + 1,
+			ID:      models.BuildID(a.forgeVersion),
+			Version: version,
+			Channel: channel,
+			Stable:  channel == "RECOMMENDED",
+			Downloads: []models.Download{
+				{
+					Kind:        models.DownloadKindInstaller,
+					Name:        fmt.Sprintf("forge-%s-installer.jar", a.maven),
+					UpstreamURL: downloadURL,
+				},
+			},
+		})
+	}
+
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("version %s not found", version)
+	}
+
+	// Maven lists builds oldest first
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].Number > builds[j].Number
+	})
+
+	return builds, nil
+}
+
+func (p *ForgeProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	builds, err := p.GetBuilds(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return findBuild(builds, version, build)
+}
+
+func (p *ForgeProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
+	builds, err := p.GetBuilds(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer the recommended promotion, then the latest one, then the newest build
+	for _, channel := range []string{"RECOMMENDED", "LATEST"} {
+		for i := range builds {
+			if builds[i].Channel == channel {
+				return &builds[i], nil
+			}
+		}
+	}
+
+	return &builds[0], nil
+}
+
+func (p *ForgeProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
+	b, err := p.GetBuild(ctx, version, build)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b.Downloads) == 0 {
+		return "", fmt.Errorf("no download available")
+	}
+
+	return b.Downloads[0].UpstreamURL, nil
+}
@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+// MavenMetadata represents a maven-metadata.xml document
+type MavenMetadata struct {
+	GroupID    string          `xml:"groupId"`
+	ArtifactID string          `xml:"artifactId"`
+	Versioning MavenVersioning `xml:"versioning"`
+}
+
+// MavenVersioning contains the versions published for an artifact
+type MavenVersioning struct {
+	Latest   string   `xml:"latest"`
+	Release  string   `xml:"release"`
+	Versions []string `xml:"versions>version"`
+}
+
+// fetchMavenMetadata fetches and decodes a maven-metadata.xml file
+func fetchMavenMetadata(ctx context.Context, client *upstream.Client, url string) (*MavenMetadata, error) {
+	var metadata MavenMetadata
+	if err := client.GetXML(ctx, url, &metadata); err != nil {
+		return nil, fmt.Errorf("fetching maven metadata: %w", err)
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+// NeoForgeProvider implements Provider for NeoForge installers
+type NeoForgeProvider struct {
+	client *upstream.Client
+	config ProviderConfig
+}
+
+// NewNeoForgeProvider creates a new NeoForge provider
+func NewNeoForgeProvider(config ProviderConfig) *NeoForgeProvider {
+	return &NeoForgeProvider{
+		client: config.upstreamClient(),
+		config: config.forProvider("neoforge"),
+	}
+}
+
+func (p *NeoForgeProvider) GetID() string {
+	return "neoforge"
+}
+
+func (p *NeoForgeProvider) GetName() string {
+	return "NeoForge"
+}
+
+func (p *NeoForgeProvider) GetCategory() models.Category {
+	return models.CategoryNeoForge
+}
+
+func (p *NeoForgeProvider) GetFilters() models.CategoryFilters {
+	return models.CategoryFilters{
+		Types:    []models.VersionType{models.VersionTypeRelease},
+		Channels: []string{"ALPHA", "BETA", "STABLE"},
+		Stable:   true,
+		Java:     true,
+	}
+}
+
+// ImmutableDownloads reports that Maven release artifacts never change
+func (p *NeoForgeProvider) ImmutableDownloads() bool {
+	return true
+}
+
+// neoForgeMinecraftVersion maps a NeoForge version onto its Minecraft version.
+// NeoForge drops the leading "1." of the Minecraft version: 21.1.77 targets
+// 1.21.1 and 21.0.167 targets 1.21. Versions following Minecraft's year-based
+// scheme (26.1.0.x) carry the Minecraft version in their first three parts.
+func neoForgeMinecraftVersion(version string) string {
+	mainPart := strings.SplitN(version, "-", 2)[0]
+	parts := strings.Split(mainPart, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	if len(parts) >= 4 {
+		if parts[2] == "0" {
+			return parts[0] + "." + parts[1]
+		}
+		return parts[0] + "." + parts[1] + "." + parts[2]
+	}
+
+	if parts[1] == "0" {
+		return "1." + parts[0]
+	}
+	return "1." + parts[0] + "." + parts[1]
+}
+
+// neoForgeChannel derives the build channel from a NeoForge version suffix
+func neoForgeChannel(version string) string {
+	v := strings.ToLower(version)
+	switch {
+	case strings.Contains(v, "alpha"):
+		return "ALPHA"
+	case strings.Contains(v, "beta"):
+		return "BETA"
+	default:
+		return "STABLE"
+	}
+}
+
+// fetchVersions returns all NeoForge versions from Maven (oldest first)
+func (p *NeoForgeProvider) fetchVersions(ctx context.Context) ([]string, error) {
+	metadata, err := fetchMavenMetadata(ctx, p.client, p.config.URLs.NeoForgeMaven+"/maven-metadata.xml")
+	if err != nil {
+		return nil, err
+	}
+	return metadata.Versioning.Versions, nil
+}
+
+func (p *NeoForgeProvider) GetVersions(ctx context.Context) ([]models.Version, error) {
+	neoVersions, err := p.fetchVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	versions := make([]models.Version, 0)
+	for _, nv := range neoVersions {
+		mcVersion := neoForgeMinecraftVersion(nv)
+		if mcVersion == "" {
+			continue
+		}
+
+		idx, ok := index[mcVersion]
+		if !ok {
+			idx = len(versions)
+			index[mcVersion] = idx
+			versions = append(versions, models.Version{
+				ID:   mcVersion,
+				Type: models.VersionTypeRelease,
+			})
+		}
+
+		if neoForgeChannel(nv) == "STABLE" {
+			versions[idx].Stable = true
+		}
+	}
+
+	// Sort by semantic version (newest first)
+	p.SortVersions(versions)
+
+	return versions, nil
+}
+
+// SortVersions sorts versions by semantic version, newest first
+func (p *NeoForgeProvider) SortVersions(versions []models.Version) {
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].ID, versions[j].ID) > 0
+	})
+}
+
+func (p *NeoForgeProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
+	neoVersions, err := p.fetchVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := make([]models.Build, 0)
+	for _, nv := range neoVersions {
+		if neoForgeMinecraftVersion(nv) != version {
+			continue
+		}
+
+		channel := neoForgeChannel(nv)
+		downloadURL := fmt.Sprintf("%s/%s/neoforge-%s-installer.jar", p.config.URLs.NeoForgeMaven, nv, nv)
+
+		builds = append(builds, models.Build{
+			Number:  len(builds) + 1,
+			ID:      models.BuildID(nv),
+			Version: version,
+			Channel: channel,
+			Stable:  channel == "STABLE",
+			Downloads: []models.Download{
+				{
+					Kind:        models.DownloadKindInstaller,
+					Name:        fmt.Sprintf("neoforge-%s-installer.jar", nv),
+					UpstreamURL: downloadURL,
+				},
+			},
+		})
+	}
+
+	if len(builds) == 0 {
+		return nil, fmt.Errorf("version %s not found", version)
+	}
+
+	// Maven lists builds oldest first
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].Number > builds[j].Number
+	})
+
+	return builds, nil
+}
+
+func (p *NeoForgeProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	builds, err := p.GetBuilds(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return findBuild(builds, version, build)
+}
+
+func (p *NeoForgeProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
+	builds, err := p.GetBuilds(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range builds {
+		if builds[i].Stable {
+			return &builds[i], nil
+		}
+	}
+
+	return &builds[0], nil
+}
+
+func (p *NeoForgeProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
+	b, err := p.GetBuild(ctx, version, build)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b.Downloads) == 0 {
+		return "", fmt.Errorf("no download available")
+	}
+
+	return b.Downloads[0].UpstreamURL, nil
+}
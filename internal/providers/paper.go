@@ -2,19 +2,17 @@ package providers
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
-)
-
-const (
-	fillAPIBaseURL = "https://fill.papermc.io/v3"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 )
 
 // FillVersionsResponse represents the /v3/projects/{project}/versions response
@@ -27,6 +25,9 @@ type FillVersionsResponse struct {
 				Version struct {
 					Minimum int `json:"minimum"`
 				} `json:"version"`
+				Flags struct {
+					Recommended []string `json:"recommended"`
+				} `json:"flags"`
 			} `json:"java"`
 			Support *struct {
 				Status string `json:"status"`
@@ -58,28 +59,37 @@ type FillChange struct {
 	Message string `json:"message"`
 }
 
-// VersionInfo holds support status and Java version
+// VersionInfo holds support status, Java requirement and build list
 type VersionInfo struct {
 	Supported bool
-	Java      int
+	Java      *models.JavaRequirement
+	Builds    []int
 }
 
 // PaperProvider implements Provider for PaperMC projects using Fill API v3
 type PaperProvider struct {
-	client    *http.Client
+	client    *upstream.Client
 	config    ProviderConfig
 	projectID string
 	category  models.Category
+	sync      *versionSync
+
+	mu            sync.Mutex
+	java          map[string]*models.JavaRequirement // Per version, from the last version list
+	javaRefetched time.Time                          // Last refetch of the version list on a miss
 }
 
+// javaRefetchInterval limits refetches of the Fill version list for versions
+// missing from it, so unknown versions do not cost a request each
+const javaRefetchInterval = 5 * time.Minute
+
 // NewPaperProvider creates a new Paper provider
 func NewPaperProvider(config ProviderConfig) *PaperProvider {
 	return &PaperProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config:    config,
+		client:    config.upstreamClient(),
+		config:    config.forProvider("paper"),
 		projectID: "paper",
+		sync:      newVersionSync("paper", config.State),
 		category:  models.CategoryPaper,
 	}
 }
@@ -87,11 +97,10 @@ func NewPaperProvider(config ProviderConfig) *PaperProvider {
 // NewFoliaProvider creates a new Folia provider (uses Fill API)
 func NewFoliaProvider(config ProviderConfig) *PaperProvider {
 	return &PaperProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config:    config,
+		client:    config.upstreamClient(),
+		config:    config.forProvider("folia"),
 		projectID: "folia",
+		sync:      newVersionSync("folia", config.State),
 		category:  models.CategoryFolia,
 	}
 }
@@ -99,15 +108,26 @@ func NewFoliaProvider(config ProviderConfig) *PaperProvider {
 // NewVelocityProvider creates a new Velocity provider (uses Fill API)
 func NewVelocityProvider(config ProviderConfig) *PaperProvider {
 	return &PaperProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config:    config,
+		client:    config.upstreamClient(),
+		config:    config.forProvider("velocity"),
 		projectID: "velocity",
+		sync:      newVersionSync("velocity", config.State),
 		category:  models.CategoryVelocity,
 	}
 }
 
+// NewWaterfallProvider creates a new Waterfall provider (uses Fill API)
+// Waterfall is end-of-life, but its builds remain available on Fill
+func NewWaterfallProvider(config ProviderConfig) *PaperProvider {
+	return &PaperProvider{
+		client:    config.upstreamClient(),
+		config:    config.forProvider("waterfall"),
+		projectID: "waterfall",
+		sync:      newVersionSync("waterfall", config.State),
+		category:  models.CategoryWaterfall,
+	}
+}
+
 func (p *PaperProvider) GetID() string {
 	return p.projectID
 }
@@ -120,6 +140,8 @@ func (p *PaperProvider) GetName() string {
 		return "Folia"
 	case "velocity":
 		return "Velocity"
+	case "waterfall":
+		return "Waterfall"
 	default:
 		return p.projectID
 	}
@@ -146,65 +168,94 @@ func (p *PaperProvider) GetFilters() models.CategoryFilters {
 	return filters
 }
 
-func (p *PaperProvider) doRequest(ctx context.Context, url string, target interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("User-Agent", p.config.UserAgent)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("making request: %w", err)
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("not found")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("decoding response: %w", err)
-	}
-
-	return nil
-}
-
 // fetchAllVersionInfo fetches support status and Java version for all versions in a single API call
 func (p *PaperProvider) fetchAllVersionInfo(ctx context.Context) (map[string]VersionInfo, error) {
-	url := fmt.Sprintf("%s/projects/%s/versions", fillAPIBaseURL, p.projectID)
+	url := fmt.Sprintf("%s/projects/%s/versions", p.config.URLs.Fill, p.projectID)
 
 	var versionsResp FillVersionsResponse
-	if err := p.doRequest(ctx, url, &versionsResp); err != nil {
+	if err := p.client.GetJSON(ctx, url, &versionsResp); err != nil {
 		return nil, err
 	}
 
 	results := make(map[string]VersionInfo)
 	for _, v := range versionsResp.Versions {
-		info := VersionInfo{}
+		info := VersionInfo{Builds: v.Builds}
 
 		// Check support status
 		if v.Version.Support != nil && strings.ToUpper(v.Version.Support.Status) == "SUPPORTED" {
 			info.Supported = true
 		}
 
-		// Get Java version
-		if v.Version.Java != nil {
-			info.Java = v.Version.Java.Version.Minimum
+		// Get Java requirement
+		if v.Version.Java != nil && v.Version.Java.Version.Minimum > 0 {
+			info.Java = &models.JavaRequirement{
+				Minimum: v.Version.Java.Version.Minimum,
+				Flags:   v.Version.Java.Flags.Recommended,
+			}
 		}
 
 		results[v.Version.ID] = info
 	}
 
+	// Remember the Java requirements for builds
+	javaReqs := make(map[string]*models.JavaRequirement, len(results))
+	for id, info := range results {
+		javaReqs[id] = info.Java
+	}
+	p.mu.Lock()
+	p.java = javaReqs
+	p.mu.Unlock()
+
 	return results, nil
 }
 
+// javaRequirement returns the Java requirement Fill reports for a version,
+// refetching the version list if it does not include the version yet (e.g.
+// it was released since the list was last fetched), at most once per
+// javaRefetchInterval
+func (p *PaperProvider) javaRequirement(ctx context.Context, version string) *models.JavaRequirement {
+	p.mu.Lock()
+	req, ok := p.java[version]
+	refetch := !ok && time.Since(p.javaRefetched) >= javaRefetchInterval
+	if refetch {
+		p.javaRefetched = time.Now()
+	}
+	p.mu.Unlock()
+	if !refetch {
+		return req
+	}
+
+	if _, err := p.fetchAllVersionInfo(ctx); err != nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.java[version]
+}
+
+// fillRecheckInterval is how often the builds of supported versions are
+// refetched although their build list did not change. The Fill version list
+// only carries build numbers, so a build promoted to another channel is
+// only noticed by refetching.
+const fillRecheckInterval = time.Hour
+
+// buildListFingerprint identifies a Fill build list by all of its builds
+func buildListFingerprint(builds []int) string {
+	ids := make([]string, len(builds))
+	for i, b := range builds {
+		ids[i] = strconv.Itoa(b)
+	}
+	return listFingerprint(ids)
+}
+
+// listFingerprint returns a hash of a list of build identifiers, which
+// changes whenever a build is added, removed or replaced
+func listFingerprint(ids []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
 // isStableChannel checks if a channel is considered stable
 func isStableChannel(channel string) bool {
 	ch := strings.ToUpper(channel)
@@ -343,132 +394,192 @@ func (p *PaperProvider) GetVersions(ctx context.Context) ([]models.Version, erro
 		versions = append(versions, models.Version{
 			ID:        versionID,
 			Type:      versionType,
-			Stable:    !isSnapshotVersion(versionID),
 			Supported: info.Supported,
-			Java:      info.Java,
+
+			JavaRequirement: info.Java,
 		})
 	}
 
-	// Fetch build info for each version to get release dates and check for stable builds
-	type versionBuildInfo struct {
-		index       int
-		releaseTime time.Time
-		hasStable   bool
+	// Release dates and stable builds come from the build list of each
+	// version, which is only refetched if it changed since the last sync
+	states := p.sync.load(ctx)
+	updated := make([]*models.VersionState, len(versions))
+	var changed []int
+	for i, v := range versions {
+		state, ok := states[v.ID]
+		if !ok || state.Fingerprint != buildListFingerprint(versionInfoMap[v.ID].Builds) ||
+			(v.Supported && time.Since(state.CheckedAt) >= fillRecheckInterval) {
+			changed = append(changed, i)
+		}
 	}
 
-	results := make(chan versionBuildInfo, len(versions))
+	err = p.client.ForEach(ctx, len(changed), func(ctx context.Context, n int) {
+		i := changed[n]
 
-	for i := range versions {
-		go func(idx int, version models.Version) {
-			info := versionBuildInfo{index: idx, hasStable: false}
-
-			buildsURL := fmt.Sprintf("%s/projects/%s/versions/%s/builds", fillAPIBaseURL, p.projectID, version.ID)
-			var builds []FillBuild
-			if err := p.doRequest(ctx, buildsURL, &builds); err == nil && len(builds) > 0 {
-				latestBuild := builds[0]
-				if t, err := time.Parse(time.RFC3339, latestBuild.Time); err == nil {
-					info.releaseTime = t
-				}
-
-				for _, b := range builds {
-					if isStableChannel(b.Channel) {
-						info.hasStable = true
-						break
-					}
-				}
-			}
+		buildsURL := fmt.Sprintf("%s/projects/%s/versions/%s/builds", p.config.URLs.Fill, p.projectID, versions[i].ID)
+		var builds []FillBuild
+		if err := p.client.GetJSON(ctx, buildsURL, &builds); err != nil {
+			return
+		}
 
-			results <- info
-		}(i, versions[i])
+		state := models.VersionState{
+			Fingerprint: buildListFingerprint(versionInfoMap[versions[i].ID].Builds),
+			CheckedAt:   time.Now(),
+		}
+		if len(builds) > 0 {
+			if t, err := time.Parse(time.RFC3339, builds[0].Time); err == nil {
+				state.ReleaseTime = t
+			}
+		}
+		for _, b := range builds {
+			if isStableChannel(b.Channel) {
+				state.HasStable = true
+				break
+			}
+		}
+		updated[i] = &state
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	for range versions {
-		info := <-results
-		if !info.releaseTime.IsZero() {
-			versions[info.index].ReleaseTime = info.releaseTime
+	// Versions whose builds could not be fetched keep their last known state
+	saved := make(map[string]models.VersionState)
+	for i := range versions {
+		state, ok := states[versions[i].ID]
+		if updated[i] != nil {
+			state, ok = *updated[i], true
+			saved[versions[i].ID] = state
+		}
+		if ok {
+			versions[i].ReleaseTime = state.ReleaseTime
+			versions[i].Stable = state.HasStable
 		}
-		versions[info.index].Stable = info.hasStable
 	}
+	p.sync.save(ctx, saved)
 
 	// Sort by semantic version (newest first)
+	p.SortVersions(versions)
+
+	return versions, nil
+}
+
+// SortVersions sorts versions by semantic version, newest first
+func (p *PaperProvider) SortVersions(versions []models.Version) {
 	sort.Slice(versions, func(i, j int) bool {
 		return compareVersions(versions[i].ID, versions[j].ID) > 0
 	})
-
-	return versions, nil
 }
 
 func (p *PaperProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
-	url := fmt.Sprintf("%s/projects/%s/versions/%s/builds", fillAPIBaseURL, p.projectID, version)
+	url := fmt.Sprintf("%s/projects/%s/versions/%s/builds", p.config.URLs.Fill, p.projectID, version)
 
 	var fillBuilds []FillBuild
-	if err := p.doRequest(ctx, url, &fillBuilds); err != nil {
+	if err := p.client.GetJSON(ctx, url, &fillBuilds); err != nil {
 		return nil, err
 	}
 
+	javaReq := p.javaRequirement(ctx, version)
 	builds := make([]models.Build, 0, len(fillBuilds))
 	for _, b := range fillBuilds {
-		buildTime, _ := time.Parse(time.RFC3339, b.Time)
-
-		changes := make([]models.Change, 0, len(b.Changes))
-		for _, c := range b.Changes {
-			changes = append(changes, models.Change{
-				Commit:  c.Commit,
-				Summary: c.Summary,
-			})
+		builds = append(builds, p.newBuild(version, b, javaReq))
+	}
+
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].Number > builds[j].Number
+	})
+
+	return builds, nil
+}
+
+// newBuild converts a Fill API build to a models.Build
+func (p *PaperProvider) newBuild(version string, b FillBuild, javaReq *models.JavaRequirement) models.Build {
+	buildTime, _ := time.Parse(time.RFC3339, b.Time)
+
+	changes := make([]models.Change, 0, len(b.Changes))
+	for _, c := range b.Changes {
+		changes = append(changes, models.Change{
+			Commit:  c.Commit,
+			Summary: c.Summary,
+		})
+	}
+
+	// The primary download comes first, followed by the other variants
+	primary := models.DownloadKindServer
+	if _, ok := b.Downloads[primary]; !ok {
+		primary = "proxy:default"
+	}
+
+	kinds := make([]string, 0, len(b.Downloads))
+	for kind := range b.Downloads {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if (kinds[i] == primary) != (kinds[j] == primary) {
+			return kinds[i] == primary
 		}
+		return kinds[i] < kinds[j]
+	})
 
-		var downloadURL, downloadName, sha256 string
-		if dl, ok := b.Downloads["server:default"]; ok {
-			downloadURL = dl.URL
-			sha256 = dl.SHA256
-			downloadName = fmt.Sprintf("%s-%s-%d.jar", p.projectID, version, b.ID)
-		} else if dl, ok := b.Downloads["proxy:default"]; ok {
-			downloadURL = dl.URL
-			sha256 = dl.SHA256
-			downloadName = fmt.Sprintf("%s-%s-%d.jar", p.projectID, version, b.ID)
+	downloads := []models.Download{}
+	for _, kind := range kinds {
+		dl := b.Downloads[kind]
+		if dl.URL == "" {
+			continue
 		}
 
-		downloads := []models.Download{}
-		if downloadURL != "" {
-			downloads = append(downloads, models.Download{
-				Name:        downloadName,
-				SHA256:      sha256,
-				UpstreamURL: downloadURL,
-			})
+		name := fmt.Sprintf("%s-%s-%d.jar", p.projectID, version, b.ID)
+		if kind != primary {
+			// e.g. "server:mojmap" -> paper-1.21.4-100-mojmap.jar
+			role, variant, _ := strings.Cut(kind, ":")
+			if role != "server" && role != "proxy" {
+				variant = role + "-" + variant
+			}
+			name = fmt.Sprintf("%s-%s-%d-%s.jar", p.projectID, version, b.ID, variant)
 		}
 
-		builds = append(builds, models.Build{
-			Number:    b.ID,
-			Version:   version,
-			Channel:   b.Channel,
-			Stable:    isStableChannel(b.Channel),
-			CreatedAt: buildTime,
-			Downloads: downloads,
-			Changes:   changes,
+		downloads = append(downloads, models.Download{
+			Kind:        kind,
+			Name:        name,
+			SHA256:      dl.SHA256,
+			UpstreamURL: p.config.rewriteURL(dl.URL),
 		})
 	}
 
-	sort.Slice(builds, func(i, j int) bool {
-		return builds[i].Number > builds[j].Number
-	})
+	return models.Build{
+		Number:    b.ID,
+		Version:   version,
+		Channel:   b.Channel,
+		Stable:    isStableChannel(b.Channel),
+		CreatedAt: buildTime,
+		Downloads: downloads,
+		Changes:   changes,
 
-	return builds, nil
+		JavaRequirement: javaReq,
+	}
 }
 
-func (p *PaperProvider) GetBuild(ctx context.Context, version string, build int) (*models.Build, error) {
+func (p *PaperProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	// Numeric builds are fetched directly instead of listing every build.
+	// Long all-digit identifiers may be commit hashes and need the list.
+	if buildNum, ok := build.Number(); ok && !build.IsCommitHash() {
+		url := fmt.Sprintf("%s/projects/%s/versions/%s/builds/%d", p.config.URLs.Fill, p.projectID, version, buildNum)
+
+		var fillBuild FillBuild
+		if err := p.client.GetJSON(ctx, url, &fillBuild); err != nil {
+			return nil, err
+		}
+
+		b := p.newBuild(version, fillBuild, p.javaRequirement(ctx, version))
+		return &b, nil
+	}
+
 	builds, err := p.GetBuilds(ctx, version)
 	if err != nil {
 		return nil, err
 	}
 
-	for i := range builds {
-		if builds[i].Number == build {
-			return &builds[i], nil
-		}
-	}
-
-	return nil, fmt.Errorf("build %d not found for version %s", build, version)
+	return findBuild(builds, version, build)
 }
 
 func (p *PaperProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
@@ -490,7 +601,7 @@ func (p *PaperProvider) GetLatestBuild(ctx context.Context, version string) (*mo
 	return &builds[0], nil
 }
 
-func (p *PaperProvider) GetDownloadURL(ctx context.Context, version string, build int) (string, error) {
+func (p *PaperProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
 	b, err := p.GetBuild(ctx, version, build)
 	if err != nil {
 		return "", err
@@ -0,0 +1,70 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildListFingerprint(t *testing.T) {
+	base := []int{100, 101, 102}
+
+	tests := []struct {
+		name    string
+		builds  []int
+		changed bool
+	}{
+		{"same list", []int{100, 101, 102}, false},
+		{"build added", []int{100, 101, 102, 103}, true},
+		{"old build removed", []int{101, 102}, true},
+		{"newest build removed", []int{100, 101}, true},
+		{"build replaced", []int{99, 101, 102}, true},
+		{"one removed and one added", []int{98, 100, 102}, true},
+		{"empty", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := buildListFingerprint(tt.builds) != buildListFingerprint(base)
+			if changed != tt.changed {
+				t.Errorf("fingerprint of %v changed = %v, want %v", tt.builds, changed, tt.changed)
+			}
+		})
+	}
+}
+
+func TestListFingerprintSeparatesIDs(t *testing.T) {
+	if listFingerprint([]string{"1", "23"}) == listFingerprint([]string{"12", "3"}) {
+		t.Error("fingerprints of different build lists collide")
+	}
+}
+
+func TestPaperJavaRequirementRefetchesOncePerInterval(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		fmt.Fprint(w, `{"versions":[{"builds":[1],"version":{"id":"1.21","java":{"version":{"minimum":21}}}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.URLs.Fill = srv.URL
+	p := NewPaperProvider(cfg)
+	ctx := context.Background()
+
+	if req := p.javaRequirement(ctx, "1.21"); req == nil || req.Minimum != 21 {
+		t.Fatalf("javaRequirement(1.21) = %+v, want minimum 21", req)
+	}
+	for i := 0; i < 3; i++ {
+		if req := p.javaRequirement(ctx, "1.99"); req != nil {
+			t.Fatalf("javaRequirement(1.99) = %+v, want nil", req)
+		}
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched the version list %d times, want 1", n)
+	}
+}
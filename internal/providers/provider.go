@@ -2,8 +2,16 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 )
 
 // Provider defines the interface for fetching Minecraft server JARs from different sources
@@ -27,25 +35,291 @@ type Provider interface {
 	GetBuilds(ctx context.Context, version string) ([]models.Build, error)
 
 	// GetBuild returns a specific build for a version
-	GetBuild(ctx context.Context, version string, build int) (*models.Build, error)
+	// The build can be a number, a semantic build string or a commit hash
+	GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error)
 
 	// GetLatestBuild returns the latest build for a version
 	GetLatestBuild(ctx context.Context, version string) (*models.Build, error)
 
 	// GetDownloadURL returns the download URL for a specific build
-	GetDownloadURL(ctx context.Context, version string, build int) (string, error)
+	GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error)
+}
+
+// VersionSorter is implemented by providers that sort versions themselves
+// instead of keeping the upstream's order. SortVersions orders versions
+// newest first, as GetVersions returns them.
+type VersionSorter interface {
+	SortVersions(versions []models.Version)
+}
+
+// InstallerProvider is implemented by providers whose builds pair a loader
+// with a separately released installer. Builds use the latest installer
+// unless their ID selects another one as "<loader>+<installer>".
+type InstallerProvider interface {
+	GetInstallers(ctx context.Context) ([]models.InstallerVersion, error)
+}
+
+// ImmutableDownloadsProvider is implemented by providers whose download URLs
+// always serve the same content, such as Maven releases and Jenkins build
+// artifacts. Checksums computed on download are only recorded for them.
+type ImmutableDownloadsProvider interface {
+	ImmutableDownloads() bool
 }
 
 // ProviderConfig contains configuration for providers
 type ProviderConfig struct {
 	UserAgent string
 	Timeout   int
+	URLs      UpstreamURLs
+	Client    *upstream.Client // Shared upstream client (created by NewRegistry if nil)
+
+	// MaxConcurrentPerHost bounds in-flight requests and fan-out per upstream host
+	MaxConcurrentPerHost int
+
+	// Breaker configures the per-host circuit breaker (zero = client default)
+	Breaker upstream.BreakerConfig
+
+	// ProviderURLs overrides URLs for single providers, keyed by provider ID
+	// (e.g. a separate Fill mirror for "folia")
+	ProviderURLs map[string]UpstreamURLs
+
+	// URLRewrites maps URL prefixes found in upstream responses, such as
+	// Mojang's piston-meta and piston-data URLs, to mirror prefixes
+	URLRewrites map[string]string
+
+	// State persists per-version sync state across restarts (optional)
+	State StateStore
+}
+
+// StateStore persists data derived from the build lists of versions
+type StateStore interface {
+	// VersionStates returns the recorded states of a category's versions
+	VersionStates(ctx context.Context, categoryID string) (map[string]models.VersionState, error)
+
+	// RecordVersionStates upserts states of a category's versions
+	RecordVersionStates(ctx context.Context, categoryID string, states map[string]models.VersionState) error
+}
+
+// upstreamClient returns the shared upstream client, or a new one if the
+// config does not carry one (e.g. a provider constructed on its own)
+func (c ProviderConfig) upstreamClient() *upstream.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+
+	cfg := upstream.DefaultConfig()
+	cfg.UserAgent = c.UserAgent
+	cfg.Timeout = time.Duration(c.Timeout) * time.Second
+	if c.MaxConcurrentPerHost > 0 {
+		cfg.MaxConcurrentPerHost = c.MaxConcurrentPerHost
+	}
+	if c.Breaker.FailureThreshold != 0 {
+		cfg.Breaker.FailureThreshold = c.Breaker.FailureThreshold
+	}
+	if c.Breaker.OpenDuration > 0 {
+		cfg.Breaker.OpenDuration = c.Breaker.OpenDuration
+	}
+	return upstream.New(cfg)
+}
+
+// UpstreamURLs contains the base URLs of every upstream API.
+// Each one can be pointed at an internal mirror, a caching proxy or a test server.
+type UpstreamURLs struct {
+	Fill            string `json:"fill"`             // PaperMC Fill API v3 (Paper, Folia, Velocity, Waterfall)
+	Purpur          string `json:"purpur"`           // Purpur API v2
+	MojangManifest  string `json:"mojang_manifest"`  // Mojang version manifest
+	BungeeCord      string `json:"bungeecord"`       // BungeeCord Jenkins job
+	FabricMeta      string `json:"fabric_meta"`      // Fabric meta API v2
+	ForgeMaven      string `json:"forge_maven"`      // MinecraftForge Maven artifact
+	ForgePromotions string `json:"forge_promotions"` // MinecraftForge promotions
+	NeoForgeMaven   string `json:"neoforge_maven"`   // NeoForge Maven artifact
+}
+
+// DefaultUpstreamURLs returns the official upstream URLs
+func DefaultUpstreamURLs() UpstreamURLs {
+	return UpstreamURLs{
+		Fill:            "https://fill.papermc.io/v3",
+		Purpur:          "https://api.purpurmc.org/v2/purpur",
+		MojangManifest:  "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json",
+		BungeeCord:      "https://ci.md-5.net/job/BungeeCord",
+		FabricMeta:      "https://meta.fabricmc.net/v2",
+		ForgeMaven:      "https://maven.minecraftforge.net/net/minecraftforge/forge",
+		ForgePromotions: "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json",
+		NeoForgeMaven:   "https://maven.neoforged.net/releases/net/neoforged/neoforge",
+	}
 }
 
 // DefaultConfig returns the default provider configuration
+// Upstream URLs are read from the file at PROVIDERS_CONFIG_PATH (if set),
+// then overridden by the per-provider environment variables
 func DefaultConfig() ProviderConfig {
+	file := providersFile{URLs: DefaultUpstreamURLs()}
+
+	if path := os.Getenv("PROVIDERS_CONFIG_PATH"); path != "" {
+		if err := loadProvidersFile(path, &file); err != nil {
+			log.Printf("Warning: Failed to load provider config %s: %v", path, err)
+		}
+	}
+
+	// FILL_API_URL applies to every provider, FOLIA_FILL_API_URL to Folia only
+	urls := file.URLs
+	for env, target := range urls.envTargets() {
+		if value := os.Getenv(env); value != "" {
+			*target = value
+		}
+	}
+	providerURLs := file.Providers
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		for env := range urls.envTargets() {
+			id, ok := strings.CutSuffix(key, "_"+env)
+			if !ok || id == "" || value == "" {
+				continue
+			}
+			if providerURLs == nil {
+				providerURLs = make(map[string]UpstreamURLs)
+			}
+			override := providerURLs[strings.ToLower(id)]
+			*override.envTargets()[env] = value
+			providerURLs[strings.ToLower(id)] = override
+		}
+	}
+
+	// e.g. "https://piston-data.mojang.com=https://mirror.internal/piston-data"
+	rewrites := file.Rewrites
+	if value := os.Getenv("UPSTREAM_URL_REWRITES"); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			from, to, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || from == "" {
+				log.Printf("Warning: Ignoring invalid UPSTREAM_URL_REWRITES entry %q", pair)
+				continue
+			}
+			if rewrites == nil {
+				rewrites = make(map[string]string)
+			}
+			rewrites[from] = to
+		}
+	}
+
+	maxConcurrent := 8
+	if value := os.Getenv("UPSTREAM_MAX_CONCURRENCY"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			maxConcurrent = parsed
+		}
+	}
+
+	// Consecutive failed requests before an upstream's circuit opens (-1 = disabled)
+	var breaker upstream.BreakerConfig
+	if value := os.Getenv("UPSTREAM_BREAKER_THRESHOLD"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed != 0 {
+			breaker.FailureThreshold = parsed
+		}
+	}
+	if value := os.Getenv("UPSTREAM_BREAKER_COOLDOWN"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			breaker.OpenDuration = d
+		}
+	}
+
 	return ProviderConfig{
-		UserAgent: "JarVault/1.0.0 (https://github.com/ServerwaveHost/wave-mc-jars-api; contact@serverwave.com)",
-		Timeout:   30,
+		UserAgent:            "JarVault/1.0.0 (https://github.com/ServerwaveHost/wave-mc-jars-api; contact@serverwave.com)",
+		Timeout:              30,
+		URLs:                 urls.normalize(),
+		MaxConcurrentPerHost: maxConcurrent,
+		Breaker:              breaker,
+		ProviderURLs:         providerURLs,
+		URLRewrites:          rewrites,
+	}
+}
+
+// forProvider returns the config with a provider's URL overrides applied
+func (c ProviderConfig) forProvider(id string) ProviderConfig {
+	if override, ok := c.ProviderURLs[id]; ok {
+		c.URLs.merge(override)
+		c.URLs = c.URLs.normalize()
+	}
+	return c
+}
+
+// rewriteURL maps a URL taken from an upstream response to its mirror,
+// using the longest matching prefix of URLRewrites
+func (c ProviderConfig) rewriteURL(u string) string {
+	best := ""
+	for from := range c.URLRewrites {
+		if strings.HasPrefix(u, from) && len(from) > len(best) {
+			best = from
+		}
+	}
+	if best == "" {
+		return u
+	}
+	return c.URLRewrites[best] + strings.TrimPrefix(u, best)
+}
+
+// providersFile is the JSON file referenced by PROVIDERS_CONFIG_PATH
+type providersFile struct {
+	URLs      UpstreamURLs            `json:"urls"`
+	Providers map[string]UpstreamURLs `json:"providers"`
+	Rewrites  map[string]string       `json:"rewrites"`
+}
+
+// loadProvidersFile overrides file with the non-empty values from a JSON file
+func loadProvidersFile(path string, file *providersFile) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var parsed providersFile
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("parsing provider config: %w", err)
+	}
+
+	file.URLs.merge(parsed.URLs)
+	file.Providers = parsed.Providers
+	file.Rewrites = parsed.Rewrites
+	return nil
+}
+
+// envTargets maps the environment variable of each URL to its field
+func (u *UpstreamURLs) envTargets() map[string]*string {
+	return map[string]*string{
+		"FILL_API_URL":           &u.Fill,
+		"PURPUR_API_URL":         &u.Purpur,
+		"MOJANG_MANIFEST_URL":    &u.MojangManifest,
+		"BUNGEECORD_JENKINS_URL": &u.BungeeCord,
+		"FABRIC_META_URL":        &u.FabricMeta,
+		"FORGE_MAVEN_URL":        &u.ForgeMaven,
+		"FORGE_PROMOTIONS_URL":   &u.ForgePromotions,
+		"NEOFORGE_MAVEN_URL":     &u.NeoForgeMaven,
+	}
+}
+
+// merge overrides u with the non-empty URLs of o
+func (u *UpstreamURLs) merge(o UpstreamURLs) {
+	targets := u.envTargets()
+	for env, value := range o.envTargets() {
+		if *value != "" {
+			*targets[env] = *value
+		}
+	}
+}
+
+// normalize strips trailing slashes so URLs can be joined with "/"
+func (u UpstreamURLs) normalize() UpstreamURLs {
+	for _, s := range []*string{
+		&u.Fill, &u.Purpur, &u.MojangManifest, &u.BungeeCord,
+		&u.FabricMeta, &u.ForgeMaven, &u.ForgePromotions, &u.NeoForgeMaven,
+	} {
+		*s = strings.TrimRight(*s, "/")
+	}
+	return u
+}
+
+// findBuild returns the build addressed by the given identifier
+func findBuild(builds []models.Build, version string, id models.BuildID) (*models.Build, error) {
+	if b, ok := models.FindBuild(builds, id); ok {
+		return b, nil
 	}
+	return nil, fmt.Errorf("build %s not found for version %s", id, version)
 }
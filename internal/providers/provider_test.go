@@ -0,0 +1,41 @@
+package providers
+
+import "testing"
+
+func TestForProvider(t *testing.T) {
+	c := ProviderConfig{
+		URLs: DefaultUpstreamURLs(),
+		ProviderURLs: map[string]UpstreamURLs{
+			"folia": {Fill: "https://fill.mirror.example/v3/"},
+		},
+	}
+
+	if got := c.forProvider("folia").URLs; got.Fill != "https://fill.mirror.example/v3" || got.Purpur != c.URLs.Purpur {
+		t.Errorf("forProvider(folia).URLs = %+v, want only Fill overridden", got)
+	}
+	if got := c.forProvider("paper").URLs.Fill; got != c.URLs.Fill {
+		t.Errorf("forProvider(paper).URLs.Fill = %q, want %q", got, c.URLs.Fill)
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	c := ProviderConfig{URLRewrites: map[string]string{
+		"https://piston-data.mojang.com/":            "https://mirror.example/mojang/",
+		"https://piston-data.mojang.com/v1/objects/": "https://objects.example/",
+	}}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://piston-data.mojang.com/v1/objects/abc/server.jar", "https://objects.example/abc/server.jar"},
+		{"https://piston-data.mojang.com/other/server.jar", "https://mirror.example/mojang/other/server.jar"},
+		{"https://fill-data.papermc.io/v1/paper.jar", "https://fill-data.papermc.io/v1/paper.jar"},
+	}
+
+	for _, tt := range tests {
+		if got := c.rewriteURL(tt.url); got != tt.want {
+			t.Errorf("rewriteURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -2,24 +2,22 @@ package providers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
-)
-
-const (
-	purpurAPIBaseURL = "https://api.purpurmc.org/v2/purpur"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 )
 
 // PurpurProjectResponse represents the project info from Purpur API
 type PurpurProjectResponse struct {
-	Project  string   `json:"project"`
+	Project  string `json:"project"`
+	Metadata struct {
+		Current string `json:"current"`
+	} `json:"metadata"`
 	Versions []string `json:"versions"`
 }
 
@@ -57,19 +55,24 @@ type PurpurCommit struct {
 	Timestamp   int64  `json:"timestamp"`
 }
 
+// purpurRecheckInterval is how often the build list of a version other than
+// the current one is checked for changes. Unlike Fill, the Purpur project
+// response does not list builds, so each check costs a request per version.
+const purpurRecheckInterval = 24 * time.Hour
+
 // PurpurProvider implements Provider for Purpur
 type PurpurProvider struct {
-	client *http.Client
+	client *upstream.Client
 	config ProviderConfig
+	sync   *versionSync
 }
 
 // NewPurpurProvider creates a new Purpur provider
 func NewPurpurProvider(config ProviderConfig) *PurpurProvider {
 	return &PurpurProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config: config,
+		client: config.upstreamClient(),
+		config: config.forProvider("purpur"),
+		sync:   newVersionSync("purpur", config.State),
 	}
 }
 
@@ -94,39 +97,14 @@ func (p *PurpurProvider) GetFilters() models.CategoryFilters {
 	}
 }
 
-func (p *PurpurProvider) doRequest(ctx context.Context, url string, target interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("User-Agent", p.config.UserAgent)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("making request: %w", err)
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("not found")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("decoding response: %w", err)
-	}
-
-	return nil
+// ImmutableDownloads reports that published Purpur builds never change
+func (p *PurpurProvider) ImmutableDownloads() bool {
+	return true
 }
 
 func (p *PurpurProvider) GetVersions(ctx context.Context) ([]models.Version, error) {
 	var project PurpurProjectResponse
-	if err := p.doRequest(ctx, purpurAPIBaseURL, &project); err != nil {
+	if err := p.client.GetJSON(ctx, p.config.URLs.Purpur, &project); err != nil {
 		return nil, err
 	}
 
@@ -144,48 +122,74 @@ func (p *PurpurProvider) GetVersions(ctx context.Context) ([]models.Version, err
 		versions[i], versions[j] = versions[j], versions[i]
 	}
 
-	// Fetch latest build info for each version to get release dates
-	type versionInfo struct {
-		index       int
-		releaseTime time.Time
-	}
+	// Release dates come from the latest build of each version. Only the
+	// current version and versions not checked recently are rechecked, and
+	// the latest build is only refetched if the build list changed.
+	states := p.sync.load(ctx)
+	updated := make([]*models.VersionState, len(versions))
+	err := p.client.ForEach(ctx, len(versions), func(ctx context.Context, i int) {
+		state, known := states[versions[i].ID]
+		if known && versions[i].ID != project.Metadata.Current && time.Since(state.CheckedAt) < purpurRecheckInterval {
+			return
+		}
 
-	results := make(chan versionInfo, len(versions))
-
-	for i, v := range versions {
-		go func(idx int, version string) {
-			info := versionInfo{index: idx}
-
-			// Fetch version info to get latest build
-			versionURL := fmt.Sprintf("%s/%s", purpurAPIBaseURL, version)
-			var versionResp PurpurVersionResponse
-			if err := p.doRequest(ctx, versionURL, &versionResp); err == nil && versionResp.Builds.Latest != "" {
-				// Fetch the latest build to get timestamp
-				buildURL := fmt.Sprintf("%s/%s/%s", purpurAPIBaseURL, version, versionResp.Builds.Latest)
-				var buildResp PurpurBuildResponse
-				if err := p.doRequest(ctx, buildURL, &buildResp); err == nil && buildResp.Timestamp > 0 {
-					info.releaseTime = time.UnixMilli(buildResp.Timestamp)
-				}
-			}
+		// Fetch version info to get latest build
+		versionURL := fmt.Sprintf("%s/%s", p.config.URLs.Purpur, versions[i].ID)
+		var versionResp PurpurVersionResponse
+		if err := p.client.GetJSON(ctx, versionURL, &versionResp); err != nil || versionResp.Builds.Latest == "" {
+			return
+		}
+
+		fingerprint := listFingerprint(versionResp.Builds.All)
+		if known && state.Fingerprint == fingerprint {
+			state.CheckedAt = time.Now()
+			updated[i] = &state
+			return
+		}
+
+		// Fetch the latest build to get timestamp
+		buildURL := fmt.Sprintf("%s/%s/%s", p.config.URLs.Purpur, versions[i].ID, versionResp.Builds.Latest)
+		var buildResp PurpurBuildResponse
+		if err := p.client.GetJSON(ctx, buildURL, &buildResp); err != nil {
+			return
+		}
 
-			results <- info
-		}(i, v.ID)
+		updated[i] = &models.VersionState{
+			Fingerprint: fingerprint,
+			CheckedAt:   time.Now(),
+		}
+		if buildResp.Timestamp > 0 {
+			updated[i].ReleaseTime = time.UnixMilli(buildResp.Timestamp)
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Collect results
-	for range versions {
-		info := <-results
-		if !info.releaseTime.IsZero() {
-			versions[info.index].ReleaseTime = info.releaseTime
+	saved := make(map[string]models.VersionState)
+	for i := range versions {
+		state, ok := states[versions[i].ID]
+		if updated[i] != nil {
+			state, ok = *updated[i], true
+			saved[versions[i].ID] = state
+		}
+		if ok {
+			versions[i].ReleaseTime = state.ReleaseTime
 		}
 	}
+	p.sync.save(ctx, saved)
 
 	// Re-sort by semantic version (newest first)
+	p.SortVersions(versions)
+
+	return versions, nil
+}
+
+// SortVersions sorts versions by semantic version, newest first
+func (p *PurpurProvider) SortVersions(versions []models.Version) {
 	sort.Slice(versions, func(i, j int) bool {
 		return comparePurpurVersions(versions[i].ID, versions[j].ID) > 0
 	})
-
-	return versions, nil
 }
 
 // comparePurpurVersions compares two Minecraft version strings
@@ -220,66 +224,56 @@ func comparePurpurVersions(v1, v2 string) int {
 }
 
 func (p *PurpurProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
-	url := fmt.Sprintf("%s/%s", purpurAPIBaseURL, version)
+	url := fmt.Sprintf("%s/%s", p.config.URLs.Purpur, version)
 
 	var versionResp PurpurVersionResponse
-	if err := p.doRequest(ctx, url, &versionResp); err != nil {
+	if err := p.client.GetJSON(ctx, url, &versionResp); err != nil {
 		return nil, err
 	}
 
 	// Fetch build details in parallel to get timestamps
-	type buildResult struct {
-		index int
-		build models.Build
-		err   error
-	}
-
-	results := make(chan buildResult, len(versionResp.Builds.All))
-
-	for i, buildNum := range versionResp.Builds.All {
-		go func(idx int, buildNumStr string) {
-			buildInt, _ := strconv.Atoi(buildNumStr)
-
-			// Fetch build details to get timestamp
-			buildURL := fmt.Sprintf("%s/%s/%s", purpurAPIBaseURL, version, buildNumStr)
-			var buildResp PurpurBuildResponse
-			err := p.doRequest(ctx, buildURL, &buildResp)
-
-			var createdAt time.Time
-			stable := true
-			if err == nil {
-				if buildResp.Timestamp > 0 {
-					createdAt = time.UnixMilli(buildResp.Timestamp)
-				}
-				stable = buildResp.Result == "SUCCESS"
+	builds := make([]models.Build, len(versionResp.Builds.All))
+	err := p.client.ForEach(ctx, len(versionResp.Builds.All), func(ctx context.Context, i int) {
+		buildNumStr := versionResp.Builds.All[i]
+		buildInt, _ := strconv.Atoi(buildNumStr)
+
+		// Fetch build details to get timestamp
+		buildURL := fmt.Sprintf("%s/%s/%s", p.config.URLs.Purpur, version, buildNumStr)
+		var buildResp PurpurBuildResponse
+		err := p.client.GetJSON(ctx, buildURL, &buildResp)
+
+		var createdAt time.Time
+		var changes []models.Change
+		stable := true
+		if err == nil {
+			if buildResp.Timestamp > 0 {
+				createdAt = time.UnixMilli(buildResp.Timestamp)
 			}
+			stable = buildResp.Result == "SUCCESS"
+			changes = purpurChanges(buildResp.Commits)
+		}
 
-			downloadURL := fmt.Sprintf("%s/%s/%s/download", purpurAPIBaseURL, version, buildNumStr)
-
-			results <- buildResult{
-				index: idx,
-				build: models.Build{
-					Number:    buildInt,
-					Version:   version,
-					Stable:    stable,
-					CreatedAt: createdAt,
-					Downloads: []models.Download{
-						{
-							Name:        fmt.Sprintf("purpur-%s-%s.jar", version, buildNumStr),
-							UpstreamURL: downloadURL,
-						},
-					},
+		downloadURL := fmt.Sprintf("%s/%s/%s/download", p.config.URLs.Purpur, version, buildNumStr)
+
+		builds[i] = models.Build{
+			Number:    buildInt,
+			Version:   version,
+			Stable:    stable,
+			CreatedAt: createdAt,
+			Downloads: []models.Download{
+				{
+					Kind:        models.DownloadKindServer,
+					Name:        fmt.Sprintf("purpur-%s-%s.jar", version, buildNumStr),
+					MD5:         buildResp.Md5,
+					UpstreamURL: downloadURL,
 				},
-				err: err,
-			}
-		}(i, buildNum)
-	}
-
-	// Collect results
-	builds := make([]models.Build, len(versionResp.Builds.All))
-	for range versionResp.Builds.All {
-		result := <-results
-		builds[result.index] = result.build
+			},
+			Changes: changes,
+		}
+	})
+	if err != nil {
+		// Builds not fetched yet are left empty
+		return nil, err
 	}
 
 	// Sort builds by number descending (newest first)
@@ -290,22 +284,25 @@ func (p *PurpurProvider) GetBuilds(ctx context.Context, version string) ([]model
 	return builds, nil
 }
 
-func (p *PurpurProvider) GetBuild(ctx context.Context, version string, build int) (*models.Build, error) {
-	url := fmt.Sprintf("%s/%s/%d", purpurAPIBaseURL, version, build)
+func (p *PurpurProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
+	buildNum, ok := build.Number()
+	if !ok || build.IsCommitHash() {
+		// Commit hashes (which may be all digits) need the full build list
+		builds, err := p.GetBuilds(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+		return findBuild(builds, version, build)
+	}
+
+	url := fmt.Sprintf("%s/%s/%d", p.config.URLs.Purpur, version, buildNum)
 
 	var buildResp PurpurBuildResponse
-	if err := p.doRequest(ctx, url, &buildResp); err != nil {
+	if err := p.client.GetJSON(ctx, url, &buildResp); err != nil {
 		return nil, err
 	}
 
-	changes := make([]models.Change, 0, len(buildResp.Commits))
-	for _, c := range buildResp.Commits {
-		changes = append(changes, models.Change{
-			Commit:  c.Hash,
-			Summary: c.Description,
-			Author:  c.Author,
-		})
-	}
+	changes := purpurChanges(buildResp.Commits)
 
 	// Parse timestamp (milliseconds)
 	var createdAt time.Time
@@ -313,16 +310,18 @@ func (p *PurpurProvider) GetBuild(ctx context.Context, version string, build int
 		createdAt = time.UnixMilli(buildResp.Timestamp)
 	}
 
-	downloadURL := fmt.Sprintf("%s/%s/%d/download", purpurAPIBaseURL, version, build)
+	downloadURL := fmt.Sprintf("%s/%s/%d/download", p.config.URLs.Purpur, version, buildNum)
 
 	return &models.Build{
-		Number:    build,
+		Number:    buildNum,
 		Version:   version,
 		Stable:    buildResp.Result == "SUCCESS",
 		CreatedAt: createdAt,
 		Downloads: []models.Download{
 			{
-				Name:        fmt.Sprintf("purpur-%s-%d.jar", version, build),
+				Kind:        models.DownloadKindServer,
+				Name:        fmt.Sprintf("purpur-%s-%d.jar", version, buildNum),
+				MD5:         buildResp.Md5,
 				UpstreamURL: downloadURL,
 			},
 		},
@@ -330,20 +329,39 @@ func (p *PurpurProvider) GetBuild(ctx context.Context, version string, build int
 	}, nil
 }
 
+// purpurChanges converts Purpur commits to changes
+func purpurChanges(commits []PurpurCommit) []models.Change {
+	changes := make([]models.Change, 0, len(commits))
+	for _, c := range commits {
+		changes = append(changes, models.Change{
+			Commit:  c.Hash,
+			Summary: c.Description,
+			Author:  c.Author,
+		})
+	}
+	return changes
+}
+
 func (p *PurpurProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
-	url := fmt.Sprintf("%s/%s", purpurAPIBaseURL, version)
+	url := fmt.Sprintf("%s/%s", p.config.URLs.Purpur, version)
 
 	var versionResp PurpurVersionResponse
-	if err := p.doRequest(ctx, url, &versionResp); err != nil {
+	if err := p.client.GetJSON(ctx, url, &versionResp); err != nil {
 		return nil, err
 	}
 
-	var latestBuild int
-	_, _ = fmt.Sscanf(versionResp.Builds.Latest, "%d", &latestBuild)
-
-	return p.GetBuild(ctx, version, latestBuild)
+	return p.GetBuild(ctx, version, models.BuildID(versionResp.Builds.Latest))
 }
 
-func (p *PurpurProvider) GetDownloadURL(_ context.Context, version string, build int) (string, error) {
-	return fmt.Sprintf("%s/%s/%d/download", purpurAPIBaseURL, version, build), nil
+func (p *PurpurProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
+	if buildNum, ok := build.Number(); ok && !build.IsCommitHash() {
+		return fmt.Sprintf("%s/%s/%d/download", p.config.URLs.Purpur, version, buildNum), nil
+	}
+
+	b, err := p.GetBuild(ctx, version, build)
+	if err != nil {
+		return "", err
+	}
+
+	return b.Downloads[0].UpstreamURL, nil
 }
@@ -0,0 +1,39 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPurpurGetVersionsCancelledSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `{"project":"purpur","metadata":{"current":"1.21"},"versions":["1.20","1.21"]}`)
+		default:
+			// The refresh is cancelled while the versions are fanned out
+			cancel()
+			fmt.Fprint(w, `{"builds":{"latest":"5","all":["5"]}}`)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.URLs.Purpur = srv.URL
+	p := NewPurpurProvider(cfg)
+
+	versions, err := p.GetVersions(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetVersions() = %d versions, error %v, want context.Canceled", len(versions), err)
+	}
+	if states := p.sync.load(context.Background()); len(states) != 0 {
+		t.Errorf("saved %d version states from a cancelled sync, want 0", len(states))
+	}
+}
@@ -17,15 +17,24 @@ func NewRegistry(config ProviderConfig) *Registry {
 		providers: make(map[string]Provider),
 	}
 
+	// Share one upstream client (and its connection pools) between all providers
+	if config.Client == nil {
+		config.Client = config.upstreamClient()
+	}
+
 	// Register game server providers
 	r.Register(NewVanillaProvider(config))
 	r.Register(NewPaperProvider(config))
 	r.Register(NewFoliaProvider(config))
 	r.Register(NewPurpurProvider(config))
+	r.Register(NewFabricProvider(config))
+	r.Register(NewForgeProvider(config))
+	r.Register(NewNeoForgeProvider(config))
 
 	// Register proxy providers
 	r.Register(NewVelocityProvider(config))
 	r.Register(NewBungeeCordProvider(config))
+	r.Register(NewWaterfallProvider(config))
 
 	return r
 }
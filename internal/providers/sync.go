@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+)
+
+// versionSync keeps the data derived from the build list of every version of
+// a category, so GetVersions only refetches builds of versions that changed.
+// States are persisted in the StateStore, if configured.
+type versionSync struct {
+	categoryID string
+	store      StateStore
+
+	mu     sync.Mutex
+	loaded bool
+	states map[string]models.VersionState
+}
+
+func newVersionSync(categoryID string, store StateStore) *versionSync {
+	return &versionSync{
+		categoryID: categoryID,
+		store:      store,
+		states:     make(map[string]models.VersionState),
+	}
+}
+
+// load returns a copy of the known states, loading the persisted ones on first use
+func (s *versionSync) load(ctx context.Context) map[string]models.VersionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded && s.store != nil {
+		states, err := s.store.VersionStates(ctx, s.categoryID)
+		if err != nil {
+			log.Printf("Warning: Failed to load %s version states: %v", s.categoryID, err)
+		} else {
+			for id, state := range states {
+				s.states[id] = state
+			}
+			s.loaded = true
+		}
+	}
+
+	states := make(map[string]models.VersionState, len(s.states))
+	for id, state := range s.states {
+		states[id] = state
+	}
+	return states
+}
+
+// save records updated states
+func (s *versionSync) save(ctx context.Context, updated map[string]models.VersionState) {
+	if len(updated) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for id, state := range updated {
+		s.states[id] = state
+	}
+	s.mu.Unlock()
+
+	if s.store != nil {
+		if err := s.store.RecordVersionStates(ctx, s.categoryID, updated); err != nil {
+			log.Printf("Warning: Failed to record %s version states: %v", s.categoryID, err)
+		}
+	}
+}
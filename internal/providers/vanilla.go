@@ -2,17 +2,13 @@ package providers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
-)
-
-const (
-	mojangVersionManifestURL = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
 )
 
 // MojangVersionManifest represents the Mojang version manifest response
@@ -40,14 +36,20 @@ type MojangVersionEntry struct {
 
 // MojangVersionDetail represents the detailed version information
 type MojangVersionDetail struct {
-	ID        string                 `json:"id"`
-	Downloads MojangVersionDownloads `json:"downloads"`
+	ID          string                 `json:"id"`
+	Downloads   MojangVersionDownloads `json:"downloads"`
+	JavaVersion struct {
+		Component    string `json:"component"`
+		MajorVersion int    `json:"majorVersion"`
+	} `json:"javaVersion"`
 }
 
 // MojangVersionDownloads contains download URLs for a version
 type MojangVersionDownloads struct {
-	Server MojangDownloadEntry `json:"server"`
-	Client MojangDownloadEntry `json:"client"`
+	Server         MojangDownloadEntry `json:"server"`
+	Client         MojangDownloadEntry `json:"client"`
+	ServerMappings MojangDownloadEntry `json:"server_mappings"`
+	ClientMappings MojangDownloadEntry `json:"client_mappings"`
 }
 
 // MojangDownloadEntry represents a download entry
@@ -57,10 +59,18 @@ type MojangDownloadEntry struct {
 	URL  string `json:"url"`
 }
 
+// vanillaDetailBatch is the maximum number of version JSONs fetched per
+// GetVersions call, so the first sync of ~800 versions is spread over
+// several refreshes instead of delaying one request
+const vanillaDetailBatch = 100
+
 // VanillaProvider implements Provider for Mojang's vanilla server
 type VanillaProvider struct {
-	client    *http.Client
-	config    ProviderConfig
+	client *upstream.Client
+	config ProviderConfig
+	sync   *versionSync
+
+	mu        sync.Mutex
 	manifest  *MojangVersionManifest
 	cacheTime time.Time
 }
@@ -68,10 +78,9 @@ type VanillaProvider struct {
 // NewVanillaProvider creates a new vanilla provider
 func NewVanillaProvider(config ProviderConfig) *VanillaProvider {
 	return &VanillaProvider{
-		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		config: config,
+		client: config.upstreamClient(),
+		config: config.forProvider("vanilla"),
+		sync:   newVersionSync("vanilla", config.State),
 	}
 }
 
@@ -96,47 +105,41 @@ func (p *VanillaProvider) GetFilters() models.CategoryFilters {
 	}
 }
 
-func (p *VanillaProvider) fetchManifest(ctx context.Context) error {
-	// Cache for 5 minutes
-	if p.manifest != nil && time.Since(p.cacheTime) < 5*time.Minute {
-		return nil
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", mojangVersionManifestURL, nil)
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("User-Agent", p.config.UserAgent)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("fetching manifest: %w", err)
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+// ImmutableDownloads reports that Mojang download URLs are addressed by hash
+func (p *VanillaProvider) ImmutableDownloads() bool {
+	return true
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// fetchManifest returns the version manifest, cached for 5 minutes.
+// A returned manifest is never modified.
+func (p *VanillaProvider) fetchManifest(ctx context.Context) (*MojangVersionManifest, error) {
+	p.mu.Lock()
+	cached, cacheTime := p.manifest, p.cacheTime
+	p.mu.Unlock()
+	if cached != nil && time.Since(cacheTime) < 5*time.Minute {
+		return cached, nil
 	}
 
 	var manifest MojangVersionManifest
-	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
-		return fmt.Errorf("decoding manifest: %w", err)
+	if err := p.client.GetJSON(ctx, p.config.URLs.MojangManifest, &manifest); err != nil {
+		return nil, fmt.Errorf("fetching manifest: %w", err)
 	}
 
+	p.mu.Lock()
 	p.manifest = &manifest
 	p.cacheTime = time.Now()
-	return nil
+	p.mu.Unlock()
+	return &manifest, nil
 }
 
 func (p *VanillaProvider) GetVersions(ctx context.Context) ([]models.Version, error) {
-	if err := p.fetchManifest(ctx); err != nil {
+	manifest, err := p.fetchManifest(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	versions := make([]models.Version, 0, len(p.manifest.Versions))
-	for _, v := range p.manifest.Versions {
+	versions := make([]models.Version, 0, len(manifest.Versions))
+	for _, v := range manifest.Versions {
 		// Parse release time - Mojang uses ISO 8601 format
 		releaseTime, _ := time.Parse(time.RFC3339, v.ReleaseTime)
 
@@ -160,59 +163,109 @@ func (p *VanillaProvider) GetVersions(ctx context.Context) ([]models.Version, er
 	}
 
 	// Mojang API already returns newest first, but let's ensure it
+	p.SortVersions(versions)
+
+	if err := p.syncJavaVersions(ctx, manifest, versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// SortVersions sorts versions by release time, newest first
+func (p *VanillaProvider) SortVersions(versions []models.Version) {
 	sort.Slice(versions, func(i, j int) bool {
 		return versions[i].ReleaseTime.After(versions[j].ReleaseTime)
 	})
-
-	return versions, nil
 }
 
-func (p *VanillaProvider) fetchVersionDetail(ctx context.Context, versionURL string) (*MojangVersionDetail, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", versionURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+// syncJavaVersions sets the Java version Mojang reports in each version's
+// JSON. A version JSON only changes along with its SHA-1 in the manifest, so
+// it is fetched once, newest versions first. Versions not synced yet are
+// left to the java.json fallback. Returns ctx.Err() if the sync was cut short.
+func (p *VanillaProvider) syncJavaVersions(ctx context.Context, manifest *MojangVersionManifest, versions []models.Version) error {
+	entries := make(map[string]MojangVersionEntry, len(manifest.Versions))
+	for _, e := range manifest.Versions {
+		entries[e.ID] = e
+	}
+
+	states := p.sync.load(ctx)
+	var missing []int
+	for i := range versions {
+		if state, ok := states[versions[i].ID]; ok && state.Fingerprint == entries[versions[i].ID].SHA1 {
+			versions[i].JavaRequirement = mojangJava(state.Java)
+			continue
+		}
+		if len(missing) < vanillaDetailBatch {
+			missing = append(missing, i)
+		}
 	}
-	req.Header.Set("User-Agent", p.config.UserAgent)
 
-	resp, err := p.client.Do(req)
+	updated := make([]*models.VersionState, len(versions))
+	err := p.client.ForEach(ctx, len(missing), func(ctx context.Context, n int) {
+		i := missing[n]
+		entry := entries[versions[i].ID]
+
+		detail, err := p.fetchVersionDetail(ctx, entry.URL)
+		if err != nil {
+			return
+		}
+		updated[i] = &models.VersionState{
+			Fingerprint: entry.SHA1,
+			ReleaseTime: versions[i].ReleaseTime,
+			Java:        detail.JavaVersion.MajorVersion,
+			CheckedAt:   time.Now(),
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("fetching version detail: %w", err)
+		return err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	saved := make(map[string]models.VersionState)
+	for i := range versions {
+		if updated[i] != nil {
+			versions[i].JavaRequirement = mojangJava(updated[i].Java)
+			saved[versions[i].ID] = *updated[i]
+		}
 	}
+	p.sync.save(ctx, saved)
+	return nil
+}
 
+// mojangJava returns the requirement for the Java major version Mojang
+// reports (and bundles with the launcher), or nil if it is unknown
+func mojangJava(majorVersion int) *models.JavaRequirement {
+	if majorVersion == 0 {
+		return nil
+	}
+	return &models.JavaRequirement{Minimum: majorVersion}
+}
+
+func (p *VanillaProvider) fetchVersionDetail(ctx context.Context, versionURL string) (*MojangVersionDetail, error) {
 	var detail MojangVersionDetail
-	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
-		return nil, fmt.Errorf("decoding version detail: %w", err)
+	if err := p.client.GetJSON(ctx, p.config.rewriteURL(versionURL), &detail); err != nil {
+		return nil, fmt.Errorf("fetching version detail: %w", err)
 	}
 
 	return &detail, nil
 }
 
-func (p *VanillaProvider) findVersion(version string) (*MojangVersionEntry, error) {
-	if p.manifest == nil {
-		return nil, fmt.Errorf("manifest not loaded")
-	}
-
-	for i := range p.manifest.Versions {
-		if p.manifest.Versions[i].ID == version {
-			return &p.manifest.Versions[i], nil
+func findVersion(manifest *MojangVersionManifest, version string) (*MojangVersionEntry, error) {
+	for i := range manifest.Versions {
+		if manifest.Versions[i].ID == version {
+			return &manifest.Versions[i], nil
 		}
 	}
 	return nil, fmt.Errorf("version %s not found", version)
 }
 
 func (p *VanillaProvider) GetBuilds(ctx context.Context, version string) ([]models.Build, error) {
-	if err := p.fetchManifest(ctx); err != nil {
+	manifest, err := p.fetchManifest(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	versionEntry, err := p.findVersion(version)
+	versionEntry, err := findVersion(manifest, version)
 	if err != nil {
 		return nil, err
 	}
@@ -230,42 +283,77 @@ func (p *VanillaProvider) GetBuilds(ctx context.Context, version string) ([]mode
 	// Parse release time for the build
 	releaseTime, _ := time.Parse(time.RFC3339, versionEntry.ReleaseTime)
 
+	// Mojang's javaVersion is authoritative; remember it for the versions list
+	javaVersion := detail.JavaVersion.MajorVersion
+	if state, ok := p.sync.load(ctx)[version]; !ok || state.Fingerprint != versionEntry.SHA1 {
+		p.sync.save(ctx, map[string]models.VersionState{
+			version: {
+				Fingerprint: versionEntry.SHA1,
+				ReleaseTime: releaseTime,
+				Java:        javaVersion,
+				CheckedAt:   time.Now(),
+			},
+		})
+	}
+
+	// The server jar comes first, followed by the client jar and the
+	// obfuscation mappings where available
+	variants := []struct {
+		kind  string
+		name  string
+		entry MojangDownloadEntry
+	}{
+		{models.DownloadKindServer, fmt.Sprintf("server-%s.jar", version), detail.Downloads.Server},
+		{models.DownloadKindClient, fmt.Sprintf("client-%s.jar", version), detail.Downloads.Client},
+		{models.DownloadKindServerMappings, fmt.Sprintf("server-%s-mappings.txt", version), detail.Downloads.ServerMappings},
+		{models.DownloadKindClientMappings, fmt.Sprintf("client-%s-mappings.txt", version), detail.Downloads.ClientMappings},
+	}
+
+	downloads := make([]models.Download, 0, len(variants))
+	for _, v := range variants {
+		if v.entry.URL == "" {
+			continue
+		}
+		downloads = append(downloads, models.Download{
+			Kind:        v.kind,
+			Name:        v.name,
+			SHA1:        v.entry.SHA1,
+			Size:        v.entry.Size,
+			UpstreamURL: p.config.rewriteURL(v.entry.URL),
+		})
+	}
+
 	build := models.Build{
 		Number:    1,
 		Version:   version,
 		Stable:    true,
 		CreatedAt: releaseTime,
-		Downloads: []models.Download{
-			{
-				Name:        fmt.Sprintf("server-%s.jar", version),
-				SHA1:        detail.Downloads.Server.SHA1,
-				Size:        detail.Downloads.Server.Size,
-				UpstreamURL: detail.Downloads.Server.URL,
-			},
-		},
+		Downloads: downloads,
+
+		JavaRequirement: mojangJava(javaVersion),
 	}
 
 	return []models.Build{build}, nil
 }
 
-func (p *VanillaProvider) GetBuild(ctx context.Context, version string, build int) (*models.Build, error) {
+func (p *VanillaProvider) GetBuild(ctx context.Context, version string, build models.BuildID) (*models.Build, error) {
 	builds, err := p.GetBuilds(ctx, version)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(builds) == 0 || build != 1 {
-		return nil, fmt.Errorf("build %d not found for version %s", build, version)
+	if len(builds) == 0 || !builds[0].Matches(build) {
+		return nil, fmt.Errorf("build %s not found for version %s", build, version)
 	}
 
 	return &builds[0], nil
 }
 
 func (p *VanillaProvider) GetLatestBuild(ctx context.Context, version string) (*models.Build, error) {
-	return p.GetBuild(ctx, version, 1)
+	return p.GetBuild(ctx, version, models.NewBuildNumber(1))
 }
 
-func (p *VanillaProvider) GetDownloadURL(ctx context.Context, version string, build int) (string, error) {
+func (p *VanillaProvider) GetDownloadURL(ctx context.Context, version string, build models.BuildID) (string, error) {
 	b, err := p.GetBuild(ctx, version, build)
 	if err != nil {
 		return "", err
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func newTestVanillaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifest.json":
+			fmt.Fprintf(w, `{"versions":[{"id":"1.21","type":"release","url":"%s/1.21.json","releaseTime":"2024-06-13T08:24:03+00:00","sha1":"a"}]}`, srv.URL)
+		case "/1.21.json":
+			fmt.Fprintf(w, `{"id":"1.21","downloads":{"server":{"sha1":"b","size":1,"url":"%s/server.jar"}},"javaVersion":{"majorVersion":21}}`, srv.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Run with -race: the warmer, background refreshes and request handlers
+// call the provider concurrently
+func TestVanillaConcurrentManifestAccess(t *testing.T) {
+	srv := newTestVanillaServer(t)
+
+	cfg := DefaultConfig()
+	cfg.URLs.MojangManifest = srv.URL + "/manifest.json"
+	p := NewVanillaProvider(cfg)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				if _, err := p.GetVersions(ctx); err != nil {
+					t.Errorf("GetVersions() error = %v", err)
+				}
+				return
+			}
+			builds, err := p.GetBuilds(ctx, "1.21")
+			if err != nil || len(builds) != 1 {
+				t.Errorf("GetBuilds() = %d builds, error %v", len(builds), err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
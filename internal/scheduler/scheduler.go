@@ -0,0 +1,240 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Refresher refreshes the cached catalog of a category
+type Refresher interface {
+	Refresh(ctx context.Context, categoryID string, buildVersions int) error
+}
+
+// Config holds catalog warmer configuration
+type Config struct {
+	Enabled       bool
+	Interval      time.Duration            // Default refresh interval
+	Intervals     map[string]time.Duration // Per-category refresh intervals
+	Jitter        time.Duration            // Random delay added to every interval
+	Concurrency   int                      // Maximum categories refreshed at the same time
+	Timeout       time.Duration            // Maximum duration of a single refresh
+	BuildVersions int                      // Newest versions whose builds are refreshed
+}
+
+// DefaultConfig returns default warmer configuration from environment
+func DefaultConfig() Config {
+	cfg := Config{
+		Enabled:       os.Getenv("WARMER_ENABLED") != "false",
+		Interval:      5 * time.Minute,
+		Intervals:     make(map[string]time.Duration),
+		Jitter:        30 * time.Second,
+		Concurrency:   2,
+		Timeout:       5 * time.Minute,
+		BuildVersions: 3,
+	}
+
+	if d, err := time.ParseDuration(os.Getenv("WARMER_INTERVAL")); err == nil && d > 0 {
+		cfg.Interval = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("WARMER_JITTER")); err == nil && d >= 0 {
+		cfg.Jitter = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("WARMER_CONCURRENCY")); err == nil && n > 0 {
+		cfg.Concurrency = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("WARMER_BUILD_VERSIONS")); err == nil && n >= 0 {
+		cfg.BuildVersions = n
+	}
+
+	// Per-category intervals, e.g. "paper=2m,vanilla=15m"
+	for _, pair := range strings.Split(os.Getenv("WARMER_INTERVALS"), ",") {
+		category, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			cfg.Intervals[category] = d
+		}
+	}
+
+	return cfg
+}
+
+// Status describes the refresh state of a category
+type Status struct {
+	Category     string     `json:"category"`
+	Interval     int        `json:"interval"` // Seconds
+	Running      bool       `json:"running"`
+	LastRefresh  *time.Time `json:"last_refresh,omitempty"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	LastDuration float64    `json:"last_duration,omitempty"` // Seconds
+	LastError    string     `json:"last_error,omitempty"`
+	NextRefresh  *time.Time `json:"next_refresh,omitempty"`
+}
+
+// Scheduler periodically refreshes the catalog of every category so
+// requests are served from cache
+type Scheduler struct {
+	refresher  Refresher
+	categories []string
+	config     Config
+
+	sem    chan struct{}
+	status map[string]*Status
+	mu     sync.RWMutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new scheduler for the given categories
+func New(refresher Refresher, categories []string, cfg Config) *Scheduler {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	status := make(map[string]*Status, len(categories))
+	for _, category := range categories {
+		status[category] = &Status{
+			Category: category,
+			Interval: int(cfg.interval(category).Seconds()),
+		}
+	}
+
+	return &Scheduler{
+		refresher:  refresher,
+		categories: categories,
+		config:     cfg,
+		sem:        make(chan struct{}, cfg.Concurrency),
+		status:     status,
+	}
+}
+
+// interval returns the refresh interval of a category
+func (c Config) interval(category string) time.Duration {
+	if d, ok := c.Intervals[category]; ok {
+		return d
+	}
+	return c.Interval
+}
+
+// Start starts refreshing every category in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	if !s.config.Enabled {
+		log.Println("Catalog warmer disabled")
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, category := range s.categories {
+		s.wg.Add(1)
+		go s.run(ctx, category)
+	}
+
+	log.Printf("Catalog warmer started for %d categories", len(s.categories))
+}
+
+// Stop stops the scheduler and waits for running refreshes to finish
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Status returns the refresh status of every category, sorted by category
+func (s *Scheduler) Status() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.status))
+	for _, st := range s.status {
+		statuses = append(statuses, *st)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Category < statuses[j].Category
+	})
+
+	return statuses
+}
+
+// run refreshes a category until ctx is cancelled. The first refresh
+// happens after a random jitter so categories do not start all at once.
+func (s *Scheduler) run(ctx context.Context, category string) {
+	defer s.wg.Done()
+
+	delay := s.jitter()
+	for {
+		s.setNext(category, time.Now().Add(delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Bound the number of categories refreshed at the same time
+		select {
+		case <-ctx.Done():
+			return
+		case s.sem <- struct{}{}:
+		}
+
+		s.refresh(ctx, category)
+		<-s.sem
+
+		delay = s.config.interval(category) + s.jitter()
+	}
+}
+
+// refresh runs a single refresh of a category and records its outcome
+func (s *Scheduler) refresh(ctx context.Context, category string) {
+	s.mu.Lock()
+	s.status[category].Running = true
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.refresher.Refresh(ctx, category, s.config.BuildVersions)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.status[category]
+	st.Running = false
+	st.LastRefresh = &start
+	st.LastDuration = time.Since(start).Seconds()
+	if err != nil {
+		st.LastError = err.Error()
+		log.Printf("Warning: Catalog refresh of %s failed: %v", category, err)
+		return
+	}
+	st.LastError = ""
+	st.LastSuccess = &start
+}
+
+func (s *Scheduler) setNext(category string, next time.Time) {
+	s.mu.Lock()
+	s.status[category].NextRefresh = &next
+	s.mu.Unlock()
+}
+
+// jitter returns a random delay in [0, Jitter)
+func (s *Scheduler) jitter() time.Duration {
+	if s.config.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(s.config.Jitter)))
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/cache"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+const (
+	// refreshTimeout bounds a single (coalesced or background) upstream fetch
+	refreshTimeout = 2 * time.Minute
+
+	// lockTTL is how long a replica holds the shared fetch lock for a key.
+	// It outlives the fetch, which refreshTimeout bounds, so the lock never
+	// expires while its holder is still fetching.
+	lockTTL = refreshTimeout + 10*time.Second
+
+	// lockWait is how long a replica waits for another one to populate a key
+	// before fetching it itself. Waiting stops early if the lock is released
+	// without a fresh value being stored, e.g. because the fetch failed.
+	lockWait = refreshTimeout
+
+	// lockPollInterval is how often the cache is checked while waiting
+	lockPollInterval = 200 * time.Millisecond
+
+	// hardTTLFactor is the ratio between the hard and soft expiry of entries
+	// with a per-lookup TTL, matching the default CACHE_HARD_TTL/CACHE_TTL
+	hardTTLFactor = 6
+)
+
+// loadCached returns the cached value for key, fetching and caching it for
+// ttl on a miss (0 = the cache's default expiry). Entries past their soft expiry are served immediately while a single
+// background refresh repopulates them. When the upstream is unavailable the
+// last-known-good copy is served instead of an error.
+func loadCached[T any](ctx context.Context, s *JarsService, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	var cached T
+	if entry, err := s.cache.GetEntry(ctx, key, &cached); err == nil {
+		recordCached(ctx, entry.Age())
+		if !entry.Fresh() {
+			s.refreshInBackground(key, func(ctx context.Context) error {
+				_, err := coalesce(ctx, s, key, ttl, fetch)
+				return err
+			})
+		}
+		return cached, nil
+	}
+
+	value, err := coalesce(ctx, s, key, ttl, fetch)
+	if err != nil {
+		var stale T
+		if s.loadStale(ctx, key, err, &stale) {
+			return stale, nil
+		}
+		return value, err
+	}
+
+	recordAge(ctx, 0)
+	return value, nil
+}
+
+// coalesced is the result of a shared fetch with the response metadata the
+// fetch recorded, which every caller sharing it merges into its own
+type coalesced struct {
+	value interface{}
+	meta  *ResponseMeta
+}
+
+// coalesce fetches and stores key so that only one fetch per key is in flight
+// in this process, and across replicas when the cache supports locking.
+// Concurrent callers share the result. The fetch is detached from the
+// caller's cancellation so one caller going away does not fail the others,
+// while the caller itself stops waiting once ctx is done. It records its
+// response metadata separately from the request that started it.
+func coalesce[T any](ctx context.Context, s *JarsService, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	v, err := s.inflight.do(ctx, key, func() (interface{}, error) {
+		fetchCtx, meta := WithResponseMeta(context.WithoutCancel(ctx))
+		fetchCtx, cancel := context.WithTimeout(fetchCtx, refreshTimeout)
+		defer cancel()
+
+		value, err := fetchLocked(fetchCtx, s, key, ttl, fetch)
+		return coalesced{value: value, meta: meta}, err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	result := v.(coalesced)
+	mergeMeta(ctx, result.meta)
+	return result.value.(T), nil
+}
+
+// fetchLocked fetches and stores key while holding the shared lock for it.
+// If another replica holds the lock, it waits for that replica to store a
+// fresh value, and fetches itself if the lock is released without one or
+// after lockWait.
+func fetchLocked[T any](ctx context.Context, s *JarsService, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	if locker, ok := s.cache.(cache.Locker); ok {
+		unlock, acquired, err := locker.TryLock(ctx, lockKey(key), lockTTL)
+		if err == nil && !acquired {
+			var value T
+			var fresh bool
+			value, fresh, unlock = waitForFresh[T](ctx, s, locker, key)
+			if fresh {
+				return value, nil
+			}
+		}
+		if unlock != nil {
+			defer unlock()
+		}
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	s.store(ctx, key, value, ttl)
+	return value, nil
+}
+
+// waitForFresh polls the cache until key holds a fresh value or lockWait
+// passes. If the lock is released without a fresh value being stored, it
+// takes the lock over and returns its unlock function instead.
+func waitForFresh[T any](ctx context.Context, s *JarsService, locker cache.Locker, key string) (T, bool, func()) {
+	var zero T
+	deadline := time.Now().Add(lockWait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return zero, false, nil
+		case <-time.After(lockPollInterval):
+		}
+
+		if value, ok := loadFresh[T](ctx, s, key); ok {
+			return value, true, nil
+		}
+
+		unlock, acquired, err := locker.TryLock(ctx, lockKey(key), lockTTL)
+		if err != nil || !acquired {
+			continue
+		}
+		// The value may have been stored right before the lock was released
+		if value, ok := loadFresh[T](ctx, s, key); ok {
+			unlock()
+			return value, true, nil
+		}
+		return zero, false, unlock
+	}
+
+	return zero, false, nil
+}
+
+// loadFresh returns the cached value of key if it is fresh
+func loadFresh[T any](ctx context.Context, s *JarsService, key string) (T, bool) {
+	var value T
+	if entry, err := s.cache.GetEntry(ctx, key, &value); err == nil && entry.Fresh() {
+		recordCached(ctx, entry.Age())
+		return value, true
+	}
+	var zero T
+	return zero, false
+}
+
+// lockKey returns the cache key of the shared fetch lock for key
+func lockKey(key string) string {
+	return "lock:" + key
+}
+
+// inflightGroup deduplicates concurrent calls with the same key
+type inflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// inflightCall is a call in progress or completed
+type inflightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// do runs fn once for all concurrent callers with the same key. fn runs in
+// its own goroutine, so every caller stops waiting once its ctx is done
+// while fn completes for the others. A panic in fn is returned as an error.
+func (g *inflightGroup) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*inflightCall)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = &inflightCall{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run runs fn for a call and completes it
+func (g *inflightGroup) run(key string, c *inflightCall, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: Fetch of %s panicked: %v", key, r)
+			c.value, c.err = nil, fmt.Errorf("fetch of %s panicked: %v", key, r)
+		}
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = fn()
+}
+
+// refreshInBackground runs refresh for key unless one is already in flight
+func (s *JarsService) refreshInBackground(key string, refresh func(context.Context) error) {
+	if _, busy := s.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer s.refreshing.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		if err := refresh(ctx); err != nil {
+			log.Printf("Warning: Background refresh of %s failed: %v", key, err)
+		}
+	}()
+}
+
+// staleKey returns the cache key of the last-known-good copy of key
+func staleKey(key string) string {
+	return "stale:" + key
+}
+
+// store caches fresh data for ttl (0 = the cache's default expiry) and keeps
+// a long-lived last-known-good copy of it
+func (s *JarsService) store(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if ttl > 0 {
+		_ = s.cache.SetWithExpiry(ctx, key, value, ttl, ttl*hardTTLFactor)
+	} else {
+		_ = s.cache.Set(ctx, key, value)
+	}
+	_ = s.cache.SetWithTTL(ctx, staleKey(key), value, s.config.StaleTTL)
+}
+
+// loadStale loads the last-known-good copy of key into dest if err means the
+// upstream is unavailable. The request is marked as served stale.
+func (s *JarsService) loadStale(ctx context.Context, key string, err error, dest interface{}) bool {
+	if !isUpstreamFailure(err) {
+		return false
+	}
+
+	entry, cacheErr := s.cache.GetEntry(ctx, staleKey(key), dest)
+	if cacheErr != nil {
+		return false
+	}
+
+	markStale(ctx)
+	recordCached(ctx, entry.Age())
+	return true
+}
+
+// isUpstreamFailure reports whether err means the upstream could not answer,
+// as opposed to the requested item not existing
+func isUpstreamFailure(err error) bool {
+	return errors.Is(err, upstream.ErrUpstreamUnavailable) ||
+		errors.Is(err, upstream.ErrRateLimited) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
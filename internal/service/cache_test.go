@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/cache"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/upstream"
+)
+
+func newTestService() *JarsService {
+	return NewJarsService(nil, cache.NewMemoryCache(time.Minute, time.Hour), nil, Config{StaleTTL: time.Hour})
+}
+
+func TestCoalesceSharesOneFetch(t *testing.T) {
+	s := newTestService()
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (string, error) {
+		fetches.Add(1)
+		<-release
+		return "value", nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = coalesce(context.Background(), s, "versions:paper", 0, fetch)
+		}(i)
+	}
+
+	// Let every caller join the in-flight fetch before it completes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+	for i := range results {
+		if errs[i] != nil || results[i] != "value" {
+			t.Errorf("caller %d got %q, %v", i, results[i], errs[i])
+		}
+	}
+
+	var cached string
+	if err := s.cache.Get(context.Background(), "versions:paper", &cached); err != nil || cached != "value" {
+		t.Errorf("cache holds %q, %v after the fetch", cached, err)
+	}
+}
+
+func TestCoalesceCallerStopsOnCancel(t *testing.T) {
+	s := newTestService()
+
+	release := make(chan struct{})
+	defer close(release)
+	fetch := func(ctx context.Context) (string, error) {
+		<-release
+		return "value", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := coalesce(ctx, s, "versions:paper", 0, fetch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("coalesce() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCoalescePanicIsError(t *testing.T) {
+	s := newTestService()
+
+	_, err := coalesce(context.Background(), s, "versions:paper", 0, func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("coalesce() error = nil, want the panic as an error")
+	}
+}
+
+func TestCoalesceMetaReachesEveryCaller(t *testing.T) {
+	s := newTestService()
+
+	started := make(chan struct{})
+	var once sync.Once
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (string, error) {
+		once.Do(func() { close(started) })
+		<-release
+		// e.g. a nested lookup served from the last-known-good copy
+		markStale(ctx)
+		return "value", nil
+	}
+
+	first, firstMeta := WithResponseMeta(context.Background())
+	second, secondMeta := WithResponseMeta(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = coalesce(first, s, "versions:paper", 0, fetch)
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		_, _ = coalesce(second, s, "versions:paper", 0, fetch)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if !firstMeta.Stale() || !secondMeta.Stale() {
+		t.Errorf("stale = %v (first), %v (second), want true for both", firstMeta.Stale(), secondMeta.Stale())
+	}
+}
+
+func TestLoadCachedStaleFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		fetchErr  error
+		wantValue string
+		wantErr   error
+		wantStale bool
+	}{
+		{
+			name:      "upstream unavailable serves last-known-good",
+			fetchErr:  upstream.ErrCircuitOpen,
+			wantValue: "last-known-good",
+			wantStale: true,
+		},
+		{
+			name:      "rate limited serves last-known-good",
+			fetchErr:  upstream.ErrRateLimited,
+			wantValue: "last-known-good",
+			wantStale: true,
+		},
+		{
+			name:     "not found is returned",
+			fetchErr: upstream.ErrNotFound,
+			wantErr:  upstream.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			ctx, meta := WithResponseMeta(context.Background())
+
+			// Only the last-known-good copy survives the fresh entry
+			s.store(ctx, "versions:paper", "last-known-good", 0)
+			_ = s.cache.Delete(ctx, "versions:paper")
+
+			got, err := loadCached(ctx, s, "versions:paper", 0, func(ctx context.Context) (string, error) {
+				return "", tt.fetchErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadCached() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.wantValue {
+				t.Errorf("loadCached() = %q, want %q", got, tt.wantValue)
+			}
+			if meta.Stale() != tt.wantStale {
+				t.Errorf("stale = %v, want %v", meta.Stale(), tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestLoadCachedWithoutLastKnownGood(t *testing.T) {
+	s := newTestService()
+
+	_, err := loadCached(context.Background(), s, "versions:paper", 0, func(ctx context.Context) (string, error) {
+		return "", upstream.ErrCircuitOpen
+	})
+	if !errors.Is(err, upstream.ErrUpstreamUnavailable) {
+		t.Errorf("loadCached() error = %v, want the upstream error", err)
+	}
+}
+
+func TestLoadCachedHitSkipsFetch(t *testing.T) {
+	s := newTestService()
+	ctx, meta := WithResponseMeta(context.Background())
+	s.store(ctx, "versions:paper", "cached", 0)
+
+	got, err := loadCached(ctx, s, "versions:paper", 0, func(ctx context.Context) (string, error) {
+		t.Error("fetched despite a fresh cache entry")
+		return "", nil
+	})
+	if err != nil || got != "cached" {
+		t.Fatalf("loadCached() = %q, %v, want the cached value", got, err)
+	}
+	if !meta.Cached() || meta.Stale() {
+		t.Errorf("cached = %v, stale = %v, want a fresh cache hit", meta.Cached(), meta.Stale())
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/catalog"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/providers"
+)
+
+// recordVersions stores versions observed upstream in the catalog database
+func (s *JarsService) recordVersions(ctx context.Context, categoryID string, versions []models.Version) {
+	if s.catalog == nil {
+		return
+	}
+	if err := s.catalog.RecordVersions(ctx, categoryID, versions); err != nil {
+		log.Printf("Warning: Failed to record %s versions in catalog: %v", categoryID, err)
+	}
+}
+
+// recordBuilds stores builds observed upstream in the catalog database
+func (s *JarsService) recordBuilds(ctx context.Context, categoryID, version string, builds ...models.Build) {
+	if s.catalog == nil {
+		return
+	}
+	if err := s.catalog.RecordBuilds(ctx, categoryID, version, builds); err != nil {
+		log.Printf("Warning: Failed to record %s %s builds in catalog: %v", categoryID, version, err)
+	}
+}
+
+// RecordChecksum persists the SHA-256 and size computed while downloading
+// a build whose upstream does not report them, and drops the cached build so
+// it is served with the checksum from now on. Checksums are keyed by the
+// download URL and only recorded for providers whose downloads are immutable.
+func (s *JarsService) RecordChecksum(ctx context.Context, categoryID, version string, build *models.Build, download models.Download, sha256 string, size int64) {
+	if s.catalog == nil || !s.immutableDownloads(categoryID) {
+		return
+	}
+
+	c := catalog.Checksum{
+		URL:    download.UpstreamURL,
+		SHA256: sha256,
+		Size:   size,
+	}
+	if err := s.catalog.RecordChecksum(ctx, categoryID, version, c); err != nil {
+		log.Printf("Warning: Failed to record %s %s checksum of %s in catalog: %v", categoryID, version, c.URL, err)
+		return
+	}
+
+	keys := []string{
+		buildsKey(categoryID, version),
+		latestBuildKey(categoryID, version),
+		buildKey(categoryID, version, models.NewBuildNumber(build.Number)),
+	}
+	if build.ID != "" {
+		keys = append(keys, buildKey(categoryID, version, build.ID))
+	}
+	for _, key := range keys {
+		_ = s.cache.Delete(ctx, key)
+	}
+}
+
+// immutableDownloads reports whether the download URLs of a provider always
+// serve the same content, so a checksum computed once stays valid
+func (s *JarsService) immutableDownloads(categoryID string) bool {
+	p, err := s.registry.Get(categoryID)
+	if err != nil {
+		return false
+	}
+	immutable, ok := p.(providers.ImmutableDownloadsProvider)
+	return ok && immutable.ImmutableDownloads()
+}
+
+// fillChecksums adds the checksums computed on download to downloads whose
+// upstream does not report a SHA-256. They are marked as computed, so they
+// are only exposed and never used to verify downloads.
+func (s *JarsService) fillChecksums(ctx context.Context, categoryID, version string, builds []models.Build) {
+	if s.catalog == nil || !s.immutableDownloads(categoryID) {
+		return
+	}
+
+	checksums, err := s.catalog.Checksums(ctx, categoryID, version)
+	if err != nil {
+		log.Printf("Warning: Failed to load %s %s checksums from catalog: %v", categoryID, version, err)
+		return
+	}
+	if len(checksums) == 0 {
+		return
+	}
+
+	byURL := make(map[string]catalog.Checksum, len(checksums))
+	for _, c := range checksums {
+		byURL[c.URL] = c
+	}
+
+	for i := range builds {
+		for j := range builds[i].Downloads {
+			d := &builds[i].Downloads[j]
+			if d.SHA256 != "" {
+				continue
+			}
+			if c, ok := byURL[d.UpstreamURL]; ok {
+				d.SHA256 = c.SHA256
+				d.SHA256Computed = true
+				if d.Size == 0 {
+					d.Size = c.Size
+				}
+			}
+		}
+	}
+}
+
+// catalogVersions returns the recorded versions of a category after the
+// upstream failed to list them, sorted as the provider sorts live versions
+func (s *JarsService) catalogVersions(ctx context.Context, categoryID string, err error) []models.Version {
+	if s.catalog == nil {
+		return nil
+	}
+
+	versions, catalogErr := s.catalog.Versions(ctx, categoryID)
+	if catalogErr != nil || len(versions) == 0 {
+		return nil
+	}
+	if p, err := s.registry.Get(categoryID); err == nil {
+		if sorter, ok := p.(providers.VersionSorter); ok {
+			sorter.SortVersions(versions)
+		}
+	}
+
+	markCatalog(ctx, err)
+	return versions
+}
+
+// catalogBuilds returns the recorded builds of a version that the upstream
+// failed to list, e.g. because it no longer lists the version
+func (s *JarsService) catalogBuilds(ctx context.Context, categoryID, version string, err error) []models.Build {
+	if s.catalog == nil {
+		return nil
+	}
+
+	builds, catalogErr := s.catalog.Builds(ctx, categoryID, version)
+	if catalogErr != nil || len(builds) == 0 {
+		return nil
+	}
+
+	markCatalog(ctx, err)
+	return builds
+}
+
+// catalogBuild returns a recorded build that the upstream failed to return,
+// e.g. because it was deleted or fell out of the upstream's history
+func (s *JarsService) catalogBuild(ctx context.Context, categoryID, version string, build models.BuildID, err error) *models.Build {
+	if s.catalog == nil {
+		return nil
+	}
+
+	b, catalogErr := s.catalog.Build(ctx, categoryID, version, build)
+	if catalogErr != nil {
+		return nil
+	}
+
+	markCatalog(ctx, err)
+	return b
+}
+
+// markCatalog marks the request as served stale when the catalog was used
+// because the upstream is down, rather than because it no longer lists the item
+func markCatalog(ctx context.Context, err error) {
+	if isUpstreamFailure(err) {
+		markStale(ctx)
+	}
+}
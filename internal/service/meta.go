@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type responseMetaKey struct{}
+
+// ResponseMeta collects freshness information about the data served for a
+// single request, so handlers can surface it to clients
+type ResponseMeta struct {
+	mu     sync.Mutex
+	stale  bool
+	cached bool
+	age    time.Duration
+}
+
+// WithResponseMeta returns a context that records response metadata
+func WithResponseMeta(ctx context.Context) (context.Context, *ResponseMeta) {
+	meta := &ResponseMeta{}
+	return context.WithValue(ctx, responseMetaKey{}, meta), meta
+}
+
+// ResponseMetaFrom returns the response metadata recorder from a context, if any
+func ResponseMetaFrom(ctx context.Context) *ResponseMeta {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	return meta
+}
+
+// Stale reports whether any of the data was served from the last-known-good
+// copy because the upstream was unavailable
+func (m *ResponseMeta) Stale() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stale
+}
+
+// Cached reports whether cached data was served for the request
+func (m *ResponseMeta) Cached() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cached
+}
+
+// Age returns the age of the oldest cached data served for the request
+func (m *ResponseMeta) Age() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.age
+}
+
+// mergeMeta records the metadata collected by from, e.g. by a fetch shared
+// with other requests, for the request in ctx
+func mergeMeta(ctx context.Context, from *ResponseMeta) {
+	meta := ResponseMetaFrom(ctx)
+	if meta == nil || from == nil || meta == from {
+		return
+	}
+
+	from.mu.Lock()
+	stale, cached, age := from.stale, from.cached, from.age
+	from.mu.Unlock()
+
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	meta.stale = meta.stale || stale
+	meta.cached = meta.cached || cached
+	if age > meta.age {
+		meta.age = age
+	}
+}
+
+// recordAge records the age of data served for the request in ctx
+// (0 for data just fetched from upstream)
+func recordAge(ctx context.Context, age time.Duration) {
+	if meta := ResponseMetaFrom(ctx); meta != nil {
+		meta.mu.Lock()
+		if age > meta.age {
+			meta.age = age
+		}
+		meta.mu.Unlock()
+	}
+}
+
+// recordCached records that cached data of the given age was served for
+// the request in ctx
+func recordCached(ctx context.Context, age time.Duration) {
+	if meta := ResponseMetaFrom(ctx); meta != nil {
+		meta.mu.Lock()
+		meta.cached = true
+		meta.mu.Unlock()
+	}
+	recordAge(ctx, age)
+}
+
+// markStale records that stale data was served for the request in ctx
+func markStale(ctx context.Context) {
+	if meta := ResponseMetaFrom(ctx); meta != nil {
+		meta.mu.Lock()
+		meta.stale = true
+		meta.mu.Unlock()
+	}
+}
@@ -2,28 +2,157 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/cache"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/catalog"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/java"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/providers"
 )
 
+// Config holds service configuration
+type Config struct {
+	// StaleTTL is how long last-known-good data is kept, to be served
+	// when an upstream is unavailable
+	StaleTTL time.Duration
+
+	// TTLs are the default soft expiries of cached lookups
+	TTLs TTLs
+	// CategoryTTLs override TTLs per category; zero fields inherit
+	CategoryTTLs map[string]TTLs
+}
+
+// mutableBuildTTL is the default build details TTL of categories whose
+// builds change upstream after they are published
+const mutableBuildTTL = 10 * time.Minute
+
+// TTLs holds the soft expiry of each kind of cached lookup.
+// A zero TTL uses the cache's default expiry (CACHE_TTL).
+type TTLs struct {
+	Versions time.Duration // Version lists
+	Builds   time.Duration // Build lists
+	Build    time.Duration // Build details, which rarely change upstream (see mutableBuildTTL)
+	Latest   time.Duration // Latest build and latest version pointers
+}
+
+// DefaultConfig returns default service configuration from environment
+func DefaultConfig() Config {
+	staleTTL := 7 * 24 * 3600 // 7 days default
+	if ttlStr := os.Getenv("CACHE_STALE_TTL"); ttlStr != "" {
+		if parsed, err := strconv.Atoi(ttlStr); err == nil {
+			staleTTL = parsed
+		}
+	}
+
+	cfg := Config{
+		StaleTTL: time.Duration(staleTTL) * time.Second,
+		TTLs: TTLs{
+			Build:  7 * 24 * time.Hour,
+			Latest: 5 * time.Minute,
+		},
+		CategoryTTLs: map[string]TTLs{
+			// Forge channels come from the promotions file, and Fabric
+			// builds embed the latest installer
+			string(models.CategoryForge):    {Build: mutableBuildTTL},
+			string(models.CategoryNeoForge): {Build: mutableBuildTTL},
+			string(models.CategoryFabric):   {Build: mutableBuildTTL},
+		},
+	}
+
+	// Defaults: CACHE_VERSIONS_TTL, CACHE_BUILDS_TTL, CACHE_BUILD_TTL, CACHE_LATEST_TTL
+	// Per category: the same variables suffixed with the category, e.g. CACHE_LATEST_TTL_PAPER
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		for _, kind := range []string{"VERSIONS", "BUILDS", "BUILD", "LATEST"} {
+			prefix := "CACHE_" + kind + "_TTL"
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			ttl := time.Duration(seconds) * time.Second
+
+			if name == prefix {
+				cfg.TTLs.set(kind, ttl)
+			} else if category, ok := strings.CutPrefix(name, prefix+"_"); ok {
+				ttls := cfg.CategoryTTLs[strings.ToLower(category)]
+				ttls.set(kind, ttl)
+				cfg.CategoryTTLs[strings.ToLower(category)] = ttls
+			}
+		}
+	}
+
+	return cfg
+}
+
+// set sets the TTL of a lookup kind as named in environment variables
+func (t *TTLs) set(kind string, ttl time.Duration) {
+	switch kind {
+	case "VERSIONS":
+		t.Versions = ttl
+	case "BUILDS":
+		t.Builds = ttl
+	case "BUILD":
+		t.Build = ttl
+	case "LATEST":
+		t.Latest = ttl
+	}
+}
+
+// ttls returns the TTLs of a category, falling back to the defaults
+func (c Config) ttls(categoryID string) TTLs {
+	ttls := c.TTLs
+	override, ok := c.CategoryTTLs[categoryID]
+	if !ok {
+		return ttls
+	}
+
+	if override.Versions > 0 {
+		ttls.Versions = override.Versions
+	}
+	if override.Builds > 0 {
+		ttls.Builds = override.Builds
+	}
+	if override.Build > 0 {
+		ttls.Build = override.Build
+	}
+	if override.Latest > 0 {
+		ttls.Latest = override.Latest
+	}
+	return ttls
+}
+
 // JarsService provides high-level operations for Minecraft JAR management
 type JarsService struct {
 	registry *providers.Registry
 	cache    cache.Cache
+	catalog  catalog.Store // Optional; nil disables the catalog database
+	config   Config
+
+	refreshing sync.Map      // Cache keys with a background refresh in flight
+	inflight   inflightGroup // Coalesces concurrent fetches of the same cache key
 }
 
 // NewJarsService creates a new service instance
-func NewJarsService(registry *providers.Registry, c cache.Cache) *JarsService {
+func NewJarsService(registry *providers.Registry, c cache.Cache, store catalog.Store, cfg Config) *JarsService {
 	return &JarsService{
 		registry: registry,
 		cache:    c,
+		catalog:  store,
+		config:   cfg,
 	}
 }
 
@@ -37,6 +166,7 @@ func (s *JarsService) GetCategories(_ context.Context) []models.CategoryInfo {
 			ID:          p.GetCategory(),
 			Name:        p.GetName(),
 			Description: getCategoryDescription(p.GetCategory()),
+			Archived:    isCategoryArchived(p.GetCategory()),
 			Filters:     p.GetFilters(),
 		})
 	}
@@ -60,38 +190,58 @@ func (s *JarsService) GetCategory(_ context.Context, categoryID string) (*models
 		ID:          p.GetCategory(),
 		Name:        p.GetName(),
 		Description: getCategoryDescription(p.GetCategory()),
+		Archived:    isCategoryArchived(p.GetCategory()),
 		Filters:     p.GetFilters(),
 	}, nil
 }
 
 // GetVersions returns all versions for a category
 func (s *JarsService) GetVersions(ctx context.Context, categoryID string) ([]models.Version, error) {
-	cacheKey := fmt.Sprintf("versions:%s", categoryID)
-
-	var versions []models.Version
-	if err := s.cache.Get(ctx, cacheKey, &versions); err == nil {
-		return versions, nil
-	}
-
 	p, err := s.registry.Get(categoryID)
 	if err != nil {
 		return nil, err
 	}
 
-	versions, err = p.GetVersions(ctx)
+	versions, err := loadCached(ctx, s, versionsKey(categoryID), s.config.ttls(categoryID).Versions, s.versionsFetcher(p))
 	if err != nil {
+		if recorded := s.catalogVersions(ctx, categoryID, err); recorded != nil {
+			return recorded, nil
+		}
 		return nil, err
 	}
 
-	// Add Java requirements to each version (if not already set by provider)
-	for i := range versions {
-		if versions[i].Java == 0 {
-			versions[i].Java = java.GetRequirement(versions[i].ID, p.GetCategory())
+	return versions, nil
+}
+
+// versionsFetcher returns a fetch function for the versions of a provider
+func (s *JarsService) versionsFetcher(p providers.Provider) func(context.Context) ([]models.Version, error) {
+	return func(ctx context.Context) ([]models.Version, error) {
+		versions, err := p.GetVersions(ctx)
+		if err != nil {
+			return nil, err
 		}
+
+		// Add Java requirements to each version (if not already set by provider)
+		for i := range versions {
+			versions[i].Java = javaVersion(versions[i].JavaRequirement, versions[i].Java, versions[i].ID, p.GetCategory())
+		}
+
+		s.recordVersions(ctx, p.GetID(), versions)
+		return versions, nil
 	}
+}
 
-	_ = s.cache.Set(ctx, cacheKey, versions)
-	return versions, nil
+// javaVersion returns the Java version of a version or build: the minimum
+// reported upstream, or else the one set by the provider, or else the
+// java.json mapping
+func javaVersion(req *models.JavaRequirement, current int, version string, category models.Category) int {
+	if req != nil && req.Minimum > 0 {
+		return req.Minimum
+	}
+	if current > 0 {
+		return current
+	}
+	return java.GetRequirement(version, category)
 }
 
 // GetVersionsFiltered returns versions filtered by options
@@ -147,31 +297,160 @@ func (s *JarsService) GetVersionsFiltered(ctx context.Context, categoryID string
 
 // GetBuilds returns all builds for a category version
 func (s *JarsService) GetBuilds(ctx context.Context, categoryID, version string) ([]models.Build, error) {
-	cacheKey := fmt.Sprintf("builds:%s:%s", categoryID, version)
+	p, err := s.registry.Get(categoryID)
+	if err != nil {
+		return nil, err
+	}
 
-	var builds []models.Build
-	if err := s.cache.Get(ctx, cacheKey, &builds); err == nil {
+	builds, err := loadCached(ctx, s, buildsKey(categoryID, version), s.config.ttls(categoryID).Builds, s.buildsFetcher(p, version))
+	if err != nil {
+		if recorded := s.catalogBuilds(ctx, categoryID, version, err); recorded != nil {
+			return recorded, nil
+		}
+		return nil, err
+	}
+
+	return builds, nil
+}
+
+// buildsFetcher returns a fetch function for the builds of a provider version
+func (s *JarsService) buildsFetcher(p providers.Provider, version string) func(context.Context) ([]models.Build, error) {
+	return func(ctx context.Context) ([]models.Build, error) {
+		builds, err := p.GetBuilds(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add Java requirements to each build (if not already set by provider)
+		for i := range builds {
+			builds[i].Java = javaVersion(builds[i].JavaRequirement, builds[i].Java, version, p.GetCategory())
+		}
+
+		s.fillChecksums(ctx, p.GetID(), version, builds)
+		s.recordBuilds(ctx, p.GetID(), version, builds...)
 		return builds, nil
 	}
+}
 
+// Refresh refetches the versions of a category and the builds and latest
+// build of its latest stable version and newest buildVersions versions,
+// bypassing the cache. It is used by the background catalog warmer.
+func (s *JarsService) Refresh(ctx context.Context, categoryID string, buildVersions int) error {
 	p, err := s.registry.Get(categoryID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	ttls := s.config.ttls(categoryID)
+
+	versions, err := coalesce(ctx, s, versionsKey(categoryID), ttls.Versions, s.versionsFetcher(p))
+	if err != nil {
+		return fmt.Errorf("refreshing versions: %w", err)
+	}
+	if latest := latestStableVersion(versions); latest != nil {
+		s.store(ctx, latestVersionKey(categoryID), latest, ttls.Latest)
+	}
+
+	targets := make([]string, 0, buildVersions+1)
+	for i := 0; i < len(versions) && i < buildVersions; i++ {
+		targets = append(targets, versions[i].ID)
+	}
+	for _, v := range versions {
+		if v.Stable {
+			if !slices.Contains(targets, v.ID) {
+				targets = append(targets, v.ID)
+			}
+			break
+		}
+	}
+
+	var errs []error
+	for _, version := range targets {
+		if _, err := coalesce(ctx, s, buildsKey(categoryID, version), ttls.Builds, s.buildsFetcher(p, version)); err != nil {
+			errs = append(errs, fmt.Errorf("refreshing builds of %s: %w", version, err))
+			continue
+		}
+		if _, err := coalesce(ctx, s, latestBuildKey(categoryID, version), ttls.Latest, s.latestBuildFetcher(p, version)); err != nil {
+			errs = append(errs, fmt.Errorf("refreshing latest build of %s: %w", version, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// InvalidateCategories drops the cached versions and builds of the providers
+// of the given categories, e.g. after the Java mapping changed. The
+// last-known-good copies are kept for upstream outages.
+func (s *JarsService) InvalidateCategories(ctx context.Context, categories []models.Category) {
+	for _, p := range s.registry.List() {
+		if !slices.Contains(categories, p.GetCategory()) {
+			continue
+		}
+
+		categoryID := p.GetID()
+		for _, key := range []string{versionsKey(categoryID), latestVersionKey(categoryID)} {
+			_ = s.cache.Delete(ctx, key)
+		}
+		prefixes := []string{
+			fmt.Sprintf("builds:%s:", categoryID),
+			fmt.Sprintf("build:%s:", categoryID),
+			fmt.Sprintf("latest-build:%s:", categoryID),
+		}
+		for _, prefix := range prefixes {
+			if err := s.cache.DeletePrefix(ctx, prefix); err != nil {
+				log.Printf("Warning: Failed to invalidate cached %s entries: %v", prefix, err)
+			}
+		}
 	}
+}
 
-	builds, err = p.GetBuilds(ctx, version)
+// ErrNoInstallers is returned when installer versions are requested for a
+// category whose builds are not paired with an installer
+var ErrNoInstallers = errors.New("builds have no installer versions")
+
+// GetInstallers returns the installer versions builds of a category can be
+// paired with, for categories whose builds pair a loader with an installer
+func (s *JarsService) GetInstallers(ctx context.Context, categoryID string) ([]models.InstallerVersion, error) {
+	p, err := s.registry.Get(categoryID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add Java requirements to each build
-	javaVersion := java.GetRequirement(version, p.GetCategory())
-	for i := range builds {
-		builds[i].Java = javaVersion
+	installers, ok := p.(providers.InstallerProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", categoryID, ErrNoInstallers)
 	}
 
-	_ = s.cache.Set(ctx, cacheKey, builds)
-	return builds, nil
+	return loadCached(ctx, s, installersKey(categoryID), s.config.ttls(categoryID).Versions, installers.GetInstallers)
+}
+
+// installersKey returns the cache key of a category's installer versions
+func installersKey(categoryID string) string {
+	return fmt.Sprintf("installers:%s", categoryID)
+}
+
+// versionsKey returns the cache key of a category's versions
+func versionsKey(categoryID string) string {
+	return fmt.Sprintf("versions:%s", categoryID)
+}
+
+// buildsKey returns the cache key of a category version's builds
+func buildsKey(categoryID, version string) string {
+	return fmt.Sprintf("builds:%s:%s", categoryID, version)
+}
+
+// buildKey returns the cache key of a single build
+func buildKey(categoryID, version string, build models.BuildID) string {
+	return fmt.Sprintf("build:%s:%s:%s", categoryID, version, build)
+}
+
+// latestBuildKey returns the cache key of a category version's latest build
+func latestBuildKey(categoryID, version string) string {
+	return fmt.Sprintf("latest-build:%s:%s", categoryID, version)
+}
+
+// latestVersionKey returns the cache key of a category's latest stable version
+func latestVersionKey(categoryID string) string {
+	return fmt.Sprintf("latest-version:%s", categoryID)
 }
 
 // GetBuildsFiltered returns builds filtered by options
@@ -208,23 +487,47 @@ func (s *JarsService) GetBuildsFiltered(ctx context.Context, categoryID, version
 }
 
 // GetBuild returns a specific build
-func (s *JarsService) GetBuild(ctx context.Context, categoryID, version string, build int) (*models.Build, error) {
+// The build can be a number, a semantic build string or a commit hash
+func (s *JarsService) GetBuild(ctx context.Context, categoryID, version string, build models.BuildID) (*models.Build, error) {
 	p, err := s.registry.Get(categoryID)
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := p.GetBuild(ctx, version, build)
+	b, err := loadCached(ctx, s, buildKey(categoryID, version, build), s.config.ttls(categoryID).Build, s.buildFetcher(p, version, build))
 	if err != nil {
+		if stale := s.staleBuild(ctx, categoryID, version, err, build.String()); stale != nil {
+			return stale, nil
+		}
+		if recorded := s.catalogBuild(ctx, categoryID, version, build, err); recorded != nil {
+			return recorded, nil
+		}
 		return nil, err
 	}
 
-	// Add Java requirement
-	b.Java = java.GetRequirement(version, p.GetCategory())
-
 	return b, nil
 }
 
+// buildFetcher returns a fetch function for a single build of a provider version
+func (s *JarsService) buildFetcher(p providers.Provider, version string, build models.BuildID) func(context.Context) (*models.Build, error) {
+	return func(ctx context.Context) (*models.Build, error) {
+		b, err := p.GetBuild(ctx, version, build)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add Java requirement (if not already set by provider)
+		b.Java = javaVersion(b.JavaRequirement, b.Java, version, p.GetCategory())
+
+		filled := []models.Build{*b}
+		s.fillChecksums(ctx, p.GetID(), version, filled)
+		b = &filled[0]
+
+		s.recordBuilds(ctx, p.GetID(), version, *b)
+		return b, nil
+	}
+}
+
 // GetLatestBuild returns the latest build for a version
 func (s *JarsService) GetLatestBuild(ctx context.Context, categoryID, version string) (*models.Build, error) {
 	p, err := s.registry.Get(categoryID)
@@ -232,49 +535,110 @@ func (s *JarsService) GetLatestBuild(ctx context.Context, categoryID, version st
 		return nil, err
 	}
 
-	b, err := p.GetLatestBuild(ctx, version)
+	b, err := loadCached(ctx, s, latestBuildKey(categoryID, version), s.config.ttls(categoryID).Latest, s.latestBuildFetcher(p, version))
 	if err != nil {
+		if stale := s.staleBuild(ctx, categoryID, version, err, "latest"); stale != nil {
+			return stale, nil
+		}
 		return nil, err
 	}
 
-	// Add Java requirement
-	b.Java = java.GetRequirement(version, p.GetCategory())
-
 	return b, nil
 }
 
+// latestBuildFetcher returns a fetch function for the latest build of a provider version
+func (s *JarsService) latestBuildFetcher(p providers.Provider, version string) func(context.Context) (*models.Build, error) {
+	return func(ctx context.Context) (*models.Build, error) {
+		b, err := p.GetLatestBuild(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add Java requirement (if not already set by provider)
+		b.Java = javaVersion(b.JavaRequirement, b.Java, version, p.GetCategory())
+
+		filled := []models.Build{*b}
+		s.fillChecksums(ctx, p.GetID(), version, filled)
+		b = &filled[0]
+
+		s.recordBuilds(ctx, p.GetID(), version, *b)
+		return b, nil
+	}
+}
+
+// staleBuild finds a build in the last-known-good builds list of a version
+// when the upstream is unavailable. build is a build identifier or "latest".
+func (s *JarsService) staleBuild(ctx context.Context, categoryID, version string, err error, build string) *models.Build {
+	var builds []models.Build
+	if !s.loadStale(ctx, buildsKey(categoryID, version), err, &builds) || len(builds) == 0 {
+		return nil
+	}
+
+	if build == "latest" {
+		for i := range builds {
+			if builds[i].Stable {
+				return &builds[i]
+			}
+		}
+		return &builds[0]
+	}
+
+	b, _ := models.FindBuild(builds, models.BuildID(build))
+	return b
+}
+
 // GetLatestStableVersion returns the latest stable version for a category
 // Falls back to latest version if no stable version exists (e.g., Velocity only has SNAPSHOTs)
 func (s *JarsService) GetLatestStableVersion(ctx context.Context, categoryID string) (*models.Version, error) {
-	versions, err := s.GetVersions(ctx, categoryID)
-	if err != nil {
+	if _, err := s.registry.Get(categoryID); err != nil {
 		return nil, err
 	}
 
+	return loadCached(ctx, s, latestVersionKey(categoryID), s.config.ttls(categoryID).Latest, func(ctx context.Context) (*models.Version, error) {
+		versions, err := s.GetVersions(ctx, categoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		latest := latestStableVersion(versions)
+		if latest == nil {
+			return nil, fmt.Errorf("no versions found for %s", categoryID)
+		}
+		return latest, nil
+	})
+}
+
+// latestStableVersion returns the first stable version of a newest-first list
+func latestStableVersion(versions []models.Version) *models.Version {
 	if len(versions) == 0 {
-		return nil, fmt.Errorf("no versions found for %s", categoryID)
+		return nil
 	}
 
 	// Try to find a stable version first
 	for _, v := range versions {
 		if v.Stable {
-			return &v, nil
+			return &v
 		}
 	}
 
 	// No stable version found, return the latest (first in list) as fallback
 	// This handles cases like Velocity where all versions are SNAPSHOTs
-	return &versions[0], nil
+	return &versions[0]
 }
 
 // GetDownloadURL returns the download URL for a specific build
-func (s *JarsService) GetDownloadURL(ctx context.Context, categoryID, version string, build int) (string, error) {
-	p, err := s.registry.Get(categoryID)
+// Resolved from the cached build details instead of asking the upstream
+func (s *JarsService) GetDownloadURL(ctx context.Context, categoryID, version string, build models.BuildID) (string, error) {
+	b, err := s.GetBuild(ctx, categoryID, version, build)
 	if err != nil {
 		return "", err
 	}
 
-	return p.GetDownloadURL(ctx, version, build)
+	if len(b.Downloads) == 0 || b.Downloads[0].UpstreamURL == "" {
+		return "", fmt.Errorf("no download available")
+	}
+
+	return b.Downloads[0].UpstreamURL, nil
 }
 
 // VersionFilterOptions contains version filter parameters
@@ -387,6 +751,10 @@ func getCategoryDescription(cat models.Category) string {
 		models.CategoryFolia:      "Paper fork with regionized multithreading",
 		models.CategoryVelocity:   "Modern, high-performance Minecraft server proxy",
 		models.CategoryBungeeCord: "Minecraft server proxy by SpigotMC",
+		models.CategoryWaterfall:  "BungeeCord fork by PaperMC (end of life)",
+		models.CategoryFabric:     "Lightweight modding toolchain server launcher",
+		models.CategoryForge:      "Classic Minecraft modding platform installer",
+		models.CategoryNeoForge:   "Community-driven Forge fork installer",
 	}
 
 	if desc, ok := descriptions[cat]; ok {
@@ -394,3 +762,8 @@ func getCategoryDescription(cat models.Category) string {
 	}
 	return ""
 }
+
+// isCategoryArchived reports whether a category is end-of-life upstream
+func isCategoryArchived(cat models.Category) bool {
+	return cat == models.CategoryWaterfall
+}
@@ -0,0 +1,104 @@
+package upstream
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the upstream while its
+// circuit breaker is open. It wraps ErrUpstreamUnavailable.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrUpstreamUnavailable)
+
+// BreakerConfig holds circuit breaker configuration
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive failed requests (after retries) before the circuit opens (0 = disabled)
+	OpenDuration     time.Duration // How long the circuit stays open before a trial request
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a consecutive-failure circuit breaker for a single upstream host
+type breaker struct {
+	config   BreakerConfig
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial request is in flight
+}
+
+// allow reports whether a request may be sent to the upstream.
+// After OpenDuration a single trial request is let through (half-open).
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.config.OpenDuration {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record reports the outcome of a request
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = breakerClosed
+		b.failures = 0
+		b.trial = false
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		b.trial = false
+	}
+}
+
+// release gives up a half-open trial slot without recording an outcome
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// breakerFor returns the circuit breaker for a host, creating it if needed
+func (c *Client) breakerFor(host string) *breaker {
+	if c.config.Breaker.FailureThreshold <= 0 {
+		return nil
+	}
+
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &breaker{config: c.config.Breaker}
+		c.breakers[host] = b
+	}
+	return b
+}
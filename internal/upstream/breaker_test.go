@@ -0,0 +1,146 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// breakerStep is one operation on a breaker and the state expected after it
+type breakerStep struct {
+	op        string // "allow", "success", "failure", "release" or "cooldown"
+	wantAllow bool   // Result of "allow"
+	wantState breakerState
+}
+
+func TestBreakerStateMachine(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name: "opens after threshold consecutive failures",
+			steps: []breakerStep{
+				{op: "failure", wantState: breakerClosed},
+				{op: "failure", wantState: breakerClosed},
+				{op: "failure", wantState: breakerOpen},
+				{op: "allow", wantAllow: false, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			steps: []breakerStep{
+				{op: "failure", wantState: breakerClosed},
+				{op: "failure", wantState: breakerClosed},
+				{op: "success", wantState: breakerClosed},
+				{op: "failure", wantState: breakerClosed},
+				{op: "failure", wantState: breakerClosed},
+				{op: "allow", wantAllow: true, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "single trial after cooldown closes on success",
+			steps: []breakerStep{
+				{op: "failure"}, {op: "failure"}, {op: "failure", wantState: breakerOpen},
+				{op: "cooldown", wantState: breakerOpen},
+				{op: "allow", wantAllow: true, wantState: breakerHalfOpen},
+				{op: "allow", wantAllow: false, wantState: breakerHalfOpen},
+				{op: "success", wantState: breakerClosed},
+				{op: "allow", wantAllow: true, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "failed trial reopens",
+			steps: []breakerStep{
+				{op: "failure"}, {op: "failure"}, {op: "failure", wantState: breakerOpen},
+				{op: "cooldown", wantState: breakerOpen},
+				{op: "allow", wantAllow: true, wantState: breakerHalfOpen},
+				{op: "failure", wantState: breakerOpen},
+				{op: "allow", wantAllow: false, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "released trial lets the next request through",
+			steps: []breakerStep{
+				{op: "failure"}, {op: "failure"}, {op: "failure", wantState: breakerOpen},
+				{op: "cooldown", wantState: breakerOpen},
+				{op: "allow", wantAllow: true, wantState: breakerHalfOpen},
+				{op: "release", wantState: breakerHalfOpen},
+				{op: "allow", wantAllow: true, wantState: breakerHalfOpen},
+				{op: "allow", wantAllow: false, wantState: breakerHalfOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{config: BreakerConfig{FailureThreshold: 3, OpenDuration: time.Minute}}
+
+			for i, step := range tt.steps {
+				switch step.op {
+				case "allow":
+					if got := b.allow(); got != step.wantAllow {
+						t.Fatalf("step %d: allow() = %v, want %v", i, got, step.wantAllow)
+					}
+				case "success":
+					b.record(true)
+				case "failure":
+					b.record(false)
+				case "release":
+					b.release()
+				case "cooldown":
+					b.openedAt = b.openedAt.Add(-b.config.OpenDuration)
+				default:
+					t.Fatalf("step %d: unknown op %q", i, step.op)
+				}
+
+				if b.state != step.wantState {
+					t.Fatalf("step %d (%s): state = %d, want %d", i, step.op, b.state, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestClientRecordsOneOutcomePerRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantFailures int
+	}{
+		{"server errors after retries", http.StatusServiceUnavailable, 1},
+		{"rate limited", http.StatusTooManyRequests, 0},
+		{"success", http.StatusOK, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			cfg := DefaultConfig()
+			cfg.MaxRetries = 2
+			cfg.BaseDelay = time.Millisecond
+			cfg.Breaker = BreakerConfig{FailureThreshold: 5, OpenDuration: time.Minute}
+			c := New(cfg)
+
+			resp, err := c.Get(context.Background(), srv.URL, nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			host := strings.TrimPrefix(srv.URL, "http://")
+			if got := c.breakerFor(host).failures; got != tt.wantFailures {
+				t.Errorf("failures = %d after %d attempts, want %d", got, attempts.Load(), tt.wantFailures)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package upstream
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// hostLimiter bounds the number of concurrent requests per upstream host
+type hostLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// acquire blocks until a request slot for host is free or ctx is done.
+// The returned function releases the slot; it is safe to call more than once.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l.limit <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	if l.slots == nil {
+		l.slots = make(map[string]chan struct{})
+	}
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-slots })
+	}, nil
+}
+
+// releasingBody releases a request slot when the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// ForEach calls fn for every index in [0, n) and waits for all calls to
+// return. Calls run on at most MaxConcurrentPerHost goroutines, since a
+// provider's fan-out usually targets a single host. No new calls are
+// started once ctx is done, and ctx.Err() is returned: some indexes may not
+// have been visited, or their calls cut short.
+func (c *Client) ForEach(ctx context.Context, n int, fn func(ctx context.Context, i int)) error {
+	workers := c.config.MaxConcurrentPerHost
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(ctx, i)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return ctx.Err()
+}
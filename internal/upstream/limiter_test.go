@@ -0,0 +1,60 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachBoundsConcurrency(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrentPerHost = 3
+	c := New(cfg)
+
+	var running, peak, visited int32
+	err := c.ForEach(context.Background(), 20, func(ctx context.Context, i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&visited, 1)
+	})
+
+	if err != nil {
+		t.Fatalf("ForEach() error = %v", err)
+	}
+	if visited != 20 {
+		t.Errorf("visited %d indexes, want 20", visited)
+	}
+	if peak > 3 {
+		t.Errorf("%d concurrent calls, want at most 3", peak)
+	}
+}
+
+func TestForEachStopsOnCancel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrentPerHost = 1
+	c := New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited int32
+	err := c.ForEach(ctx, 1000, func(ctx context.Context, i int) {
+		if atomic.AddInt32(&visited, 1) == 2 {
+			cancel()
+		}
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ForEach() error = %v, want context.Canceled", err)
+	}
+	if visited >= 1000 {
+		t.Errorf("visited all %d indexes after cancellation", visited)
+	}
+}
@@ -0,0 +1,313 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Typed errors returned by the client. Use errors.Is to check for them.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrRateLimited         = errors.New("upstream rate limited")
+)
+
+// StatusError is returned when the upstream responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Unwrap maps the status code onto the typed errors
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrUpstreamUnavailable
+	default:
+		return nil
+	}
+}
+
+// Config holds upstream client configuration
+type Config struct {
+	UserAgent string
+	Timeout   time.Duration // Per-attempt timeout (0 = no timeout)
+
+	MaxRetries int           // Retries after the first attempt on 429/5xx and network errors
+	BaseDelay  time.Duration // Initial backoff delay, doubled on every retry
+	MaxDelay   time.Duration // Upper bound for backoff and honoured Retry-After values
+
+	MaxIdleConnsPerHost  int
+	MaxConnsPerHost      int
+	MaxConcurrentPerHost int // In-flight requests per host, including fan-out workers (0 = unlimited)
+
+	Breaker BreakerConfig // Per-host circuit breaker
+}
+
+// DefaultConfig returns the default upstream client configuration
+func DefaultConfig() Config {
+	return Config{
+		UserAgent:            "JarVault/1.0.0 (https://github.com/ServerwaveHost/wave-mc-jars-api; contact@serverwave.com)",
+		Timeout:              30 * time.Second,
+		MaxRetries:           3,
+		BaseDelay:            250 * time.Millisecond,
+		MaxDelay:             10 * time.Second,
+		MaxIdleConnsPerHost:  16,
+		MaxConnsPerHost:      64,
+		MaxConcurrentPerHost: 8,
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenDuration:     30 * time.Second,
+		},
+	}
+}
+
+// Client is an HTTP client for upstream APIs with retries, backoff and
+// per-host connection pooling. It is safe for concurrent use and meant to be
+// shared by all providers.
+type Client struct {
+	http   *http.Client
+	config Config
+
+	breakers   map[string]*breaker
+	breakersMu sync.Mutex
+
+	limiter *hostLimiter
+}
+
+// New creates a new upstream client
+func New(cfg Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 0 // Limited per host instead
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+		},
+		config:   cfg,
+		breakers: make(map[string]*breaker),
+		limiter:  &hostLimiter{limit: cfg.MaxConcurrentPerHost},
+	}
+}
+
+// Get performs a GET request, retrying on 429/5xx and network errors.
+// Non-2xx responses are returned as *StatusError; on success the caller
+// must close the response body.
+func (c *Client) Get(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		drain(resp)
+		return nil, &StatusError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	return resp, nil
+}
+
+// GetJSON performs a GET request and decodes the JSON response into target
+func (c *Client) GetJSON(ctx context.Context, rawURL string, target interface{}) error {
+	resp, err := c.Get(ctx, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
+
+// GetXML performs a GET request and decodes the XML response into target
+func (c *Client) GetXML(ctx context.Context, rawURL string, target interface{}) error {
+	resp, err := c.Get(ctx, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if err := xml.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
+
+// Do sends a request, retrying with exponential backoff and jitter when the
+// upstream responds with 429/5xx or the connection fails. Retry-After is
+// honoured up to MaxDelay. Responses with any other status are returned as-is.
+// While the host's circuit breaker is open, Do fails fast with ErrCircuitOpen.
+// At most MaxConcurrentPerHost requests per host are in flight; the slot is
+// held until the returned response body is closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	b := c.breakerFor(req.URL.Host)
+	if b != nil && !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := c.doWithRetries(req)
+
+	// One outcome per request, once retries are exhausted
+	if b != nil {
+		switch {
+		case err == nil:
+			b.record(true)
+		case errors.Is(err, ErrRateLimited) || ctx.Err() != nil:
+			// Rate limiting and callers giving up say nothing about the upstream's health
+			b.release()
+		default:
+			b.record(false)
+		}
+	}
+
+	return resp, err
+}
+
+// doWithRetries sends a request, retrying on 429/5xx and network errors
+func (c *Client) doWithRetries(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		release, err := c.limiter.acquire(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(req.Clone(ctx))
+		if err != nil {
+			release()
+		} else {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		}
+
+		var retryErr error
+		var delay time.Duration
+
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			retryErr = fmt.Errorf("%w: %v", ErrUpstreamUnavailable, unwrapURLError(err))
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+			retryErr = &StatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+			if retryAfter > c.config.MaxDelay {
+				// Upstream asked us to back off longer than we are willing to wait
+				drain(resp)
+				return nil, retryErr
+			}
+			delay = retryAfter
+		default:
+			return resp, nil
+		}
+
+		if resp != nil {
+			drain(resp)
+		}
+		if attempt >= c.config.MaxRetries {
+			return nil, retryErr
+		}
+
+		if delay == 0 {
+			delay = c.backoff(attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a full-jitter exponential backoff delay for the given attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	maxDelay := c.config.BaseDelay << attempt
+	if maxDelay <= 0 || maxDelay > c.config.MaxDelay {
+		maxDelay = c.config.MaxDelay
+	}
+	if maxDelay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(maxDelay))) + 1
+}
+
+// parseRetryAfter parses a Retry-After header (seconds or HTTP date)
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// unwrapURLError strips the request URL from transport errors so upstream
+// URLs never leak into API error messages
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		var netErr net.Error
+		if errors.As(urlErr.Err, &netErr) && netErr.Timeout() {
+			return errors.New("timeout")
+		}
+		return urlErr.Err
+	}
+	return err
+}
+
+// drain discards and closes a response body so the connection can be reused
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,92 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient() *Client {
+	cfg := DefaultConfig()
+	cfg.MaxRetries = 2
+	cfg.BaseDelay = time.Millisecond
+	cfg.MaxDelay = 50 * time.Millisecond
+	cfg.Breaker = BreakerConfig{}
+	return New(cfg)
+}
+
+func TestClientGetRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // Per attempt, the last one repeating
+		retryAfter   string
+		wantErr      error
+		wantAttempts int32
+	}{
+		{"success", []int{200}, "", nil, 1},
+		{"recovers from server errors", []int{503, 502, 200}, "", nil, 3},
+		{"recovers from rate limiting", []int{429, 200}, "", nil, 2},
+		{"gives up after retries", []int{500}, "", ErrUpstreamUnavailable, 3},
+		{"rate limited after retries", []int{429}, "", ErrRateLimited, 3},
+		{"Retry-After beyond MaxDelay", []int{429}, "120", ErrRateLimited, 1},
+		{"not found is not retried", []int{404}, "", ErrNotFound, 1},
+		{"gone is not found", []int{410}, "", ErrNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1)) - 1
+				if n >= len(tt.statuses) {
+					n = len(tt.statuses) - 1
+				}
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statuses[n])
+			}))
+			defer srv.Close()
+
+			resp, err := newTestClient().Get(context.Background(), srv.URL, nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", n, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got < 58*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %v, want about an hour", future, got)
+	}
+}
@@ -9,9 +9,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/artifacts"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/cache"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/catalog"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/handlers"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/java"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/models"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/providers"
+	"github.com/ServerwaveHost/wave-mc-jars-api/internal/scheduler"
 	"github.com/ServerwaveHost/wave-mc-jars-api/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -32,6 +37,12 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Load Java version mapping
+	javaConfig := java.DefaultConfig()
+	if err := java.Load(javaConfig.Path); err != nil {
+		log.Fatalf("Java config error: %v", err)
+	}
+
 	// Initialize cache
 	cacheConfig := cache.DefaultConfig()
 	c, err := cache.New(cacheConfig)
@@ -42,26 +53,66 @@ func main() {
 		_ = c.Close()
 	}()
 
+	// Initialize catalog database
+	store, err := catalog.New(catalog.DefaultConfig())
+	if err != nil {
+		log.Printf("Warning: Catalog database initialization error: %v", err)
+	}
+	if store != nil {
+		defer func() {
+			_ = store.Close()
+		}()
+	}
+
 	// Initialize provider registry
 	providerConfig := providers.DefaultConfig()
+	if store != nil {
+		// Persist version sync state so restarts only refetch changed versions
+		providerConfig.State = store
+	}
 	registry := providers.NewRegistry(providerConfig)
 
 	// Initialize service
-	svc := service.NewJarsService(registry, c)
+	svc := service.NewJarsService(registry, c, store, service.DefaultConfig())
+
+	// Reload the Java mapping on SIGHUP or file change, dropping cached
+	// versions and builds of the categories whose mapping changed
+	java.Watch(context.Background(), javaConfig, func(categories []models.Category) {
+		svc.InvalidateCategories(context.Background(), categories)
+	})
+
+	// Initialize catalog warmer
+	warmer := scheduler.New(svc, registry.ListIDs(), scheduler.DefaultConfig())
+	warmer.Start(context.Background())
+	defer warmer.Stop()
+
+	// Initialize artifact cache
+	artifactStore, err := artifacts.New(artifacts.DefaultConfig())
+	if err != nil {
+		log.Printf("Warning: Artifact cache initialization error: %v", err)
+	}
+	if artifactStore != nil {
+		defer func() {
+			_ = artifactStore.Close()
+		}()
+	}
 
 	// Initialize handlers
-	h := handlers.NewHandler(svc)
+	handlerConfig := handlers.DefaultConfig()
+	handlerConfig.Downloader = providers.NewDownloader(providerConfig)
+	h := handlers.NewHandler(svc, warmer, artifactStore, handlerConfig)
 
 	// Setup router
 	r := gin.New()
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
+	r.Use(h.TrackFreshness)
 
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Accept, Content-Type")
+		c.Header("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Accept, Content-Type, If-None-Match, If-Modified-Since, Range, If-Range")
 		c.Header("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
@@ -75,14 +126,17 @@ func main() {
 	// Health routes
 	r.GET("/", h.HealthCheck)
 	r.GET("/health", h.HealthCheck)
+	r.GET("/status", h.GetStatus)
 
 	// Categories
 	r.GET("/categories", h.GetCategories)
 	r.GET("/categories/:category", h.GetCategory)
+	r.GET("/categories/:category/installers", h.GetInstallers)
 	r.GET("/categories/:category/versions", h.GetVersions)
 	r.GET("/categories/:category/versions/:version/builds", h.GetBuilds)
 	r.GET("/categories/:category/versions/:version/builds/:build", h.GetBuild)
 	r.GET("/categories/:category/versions/:version/builds/:build/download", h.GetDownload)
+	r.HEAD("/categories/:category/versions/:version/builds/:build/download", h.GetDownload)
 
 	// Search
 	r.GET("/search", h.Search)
@@ -92,7 +146,7 @@ func main() {
 		Addr:         ":" + port,
 		Handler:      r,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 60 * time.Second,
+		WriteTimeout: 60 * time.Second, // Lifted per request for downloads
 		IdleTimeout:  60 * time.Second,
 	}
 